@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/components"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	stdio "io"
+	"io/ioutil"
+)
+
+const (
+	yamlFormat = "yaml"
+	jsonFormat = "json"
+)
+
+var configFlags struct {
+	specs  bool
+	out    string
+	format string
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the default CMCS configuration",
+	Long: `Prints the default configuration of the search, which can be edited and passed
+to solve with --config. With --specs, the components and their parameters are
+listed instead, with the type, range and default value of every parameter.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var encode func(w stdio.Writer, value interface{}) error
+		switch configFlags.format {
+		case yamlFormat:
+			encode = func(w stdio.Writer, value interface{}) error {
+				return yaml.NewEncoder(w).Encode(value)
+			}
+		case jsonFormat:
+			encode = func(w stdio.Writer, value interface{}) error {
+				encoder := json.NewEncoder(w)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(value)
+			}
+		default:
+			return fmt.Errorf("unknown configuration format %q, expected %q or %q", configFlags.format, yamlFormat, jsonFormat)
+		}
+
+		var value interface{} = components.DefaultConfiguration()
+		if configFlags.specs {
+			value = components.Specs()
+		}
+
+		return writeOutput(configFlags.out, cmd.OutOrStdout(), func(w stdio.Writer) error {
+			return encode(w, value)
+		})
+	},
+}
+
+// reads the configuration from a YAML or JSON file, JSON being a subset of YAML
+func readConfiguration(location string) (components.Configuration, error) {
+	var config components.Configuration
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.UnmarshalStrict(data, &config)
+	return config, err
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	flags := configCmd.Flags()
+	flags.BoolVar(&configFlags.specs, "specs", false, "list the components and their parameters instead")
+	flags.StringVar(&configFlags.out, "out", "", "output file for the configuration, standard output if empty")
+	flags.StringVar(&configFlags.format, "format", yamlFormat, "configuration format, yaml or json")
+}
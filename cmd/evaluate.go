@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/io"
+	"github.com/spf13/cobra"
+)
+
+var evaluateFlags struct {
+	format string
+}
+
+var evaluateCmd = &cobra.Command{
+	Use:   "evaluate <instance> <solution>",
+	Short: "Validate a solution and print its distance",
+	Long: `Checks that the solution visits every cluster of the instance exactly once,
+and that its objective value matches the length of the tour.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		instance, err := readInstance(args[0], evaluateFlags.format)
+		if err != nil {
+			return err
+		}
+
+		solution, err := io.ImportSolution(args[1], instance)
+		if err != nil {
+			return fmt.Errorf("invalid solution: %v", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "feasible, distance: %d\n", solution.Distance)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(evaluateCmd)
+
+	evaluateCmd.Flags().StringVar(&evaluateFlags.format, "format", textFormat, "instance format, text or tsplib")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/olegnalivajev/cmcs/pkg/io"
+	"github.com/spf13/cobra"
+	stdio "io"
+)
+
+var generateFlags struct {
+	nodes        int
+	clusters     int
+	asymmetry    int
+	distribution string
+	metric       string
+	closure      bool
+	coordinates  bool
+	omit         bool
+	seed         int64
+	out          string
+	format       string
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a random GTSP instance",
+	Long: `Generates a random GTSP instance with the given number of nodes and clusters.
+The instance is written to --out, or to the standard output if no file is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkInstanceFormat(generateFlags.format); err != nil {
+			return err
+		}
+
+		rnd := newRand(cmd, generateFlags.seed)
+
+		distribution, err := gtsp.ParseClusterDistribution(generateFlags.distribution)
+		if err != nil {
+			return err
+		}
+		metric, err := gtsp.ParseMetric(generateFlags.metric)
+		if err != nil {
+			return err
+		}
+
+		options := []gtsp.Option{
+			gtsp.Asymmetric(generateFlags.asymmetry),
+			gtsp.WithClusters(distribution),
+			gtsp.WithMetric(metric),
+		}
+		if generateFlags.closure {
+			options = append(options, gtsp.WithMetricClosure())
+		}
+
+		instance, err := gtsp.NewInstance(generateFlags.nodes, generateFlags.clusters, rnd, options...)
+		if err != nil {
+			return err
+		}
+
+		return writeOutput(generateFlags.out, cmd.OutOrStdout(), func(w stdio.Writer) error {
+			var writeOptions []io.WriteOption
+			if generateFlags.coordinates {
+				writeOptions = append(writeOptions, io.WithCoordinates())
+			}
+			if generateFlags.omit {
+				writeOptions = append(writeOptions, io.OmitDistances())
+			}
+			return writeInstance(w, instance, generateFlags.format, writeOptions...)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	flags := generateCmd.Flags()
+	flags.IntVar(&generateFlags.nodes, "nodes", 100, "number of nodes")
+	flags.IntVar(&generateFlags.clusters, "clusters", 20, "number of clusters")
+	flags.IntVar(&generateFlags.asymmetry, "asymmetry", 0, "percentage by which distances may differ in each direction, 0 for a symmetric instance")
+	flags.StringVar(&generateFlags.distribution, "distribution", gtsp.UniformClusters.String(), "distribution of nodes between clusters, uniform, equal, power-law or geographic")
+	flags.StringVar(&generateFlags.metric, "metric", gtsp.Manhattan.String(), "distance metric, manhattan or euclidean")
+	flags.BoolVar(&generateFlags.closure, "metric-closure", false, "replace distances with shortest paths, so that they satisfy the triangle inequality")
+	flags.BoolVar(&generateFlags.coordinates, "coordinates", false, "list the coordinates of the nodes in the text format, which other tools can't read")
+	flags.BoolVar(&generateFlags.omit, "omit-distances", false, "list the coordinates and leave out the distance matrix of the text format if they define it")
+	flags.Int64Var(&generateFlags.seed, "seed", 0, "seed of the random number generator, current time if not set")
+	flags.StringVar(&generateFlags.out, "out", "", "output file, standard output if empty")
+	flags.StringVar(&generateFlags.format, "format", textFormat, "instance format, text or tsplib")
+}
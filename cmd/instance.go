@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/olegnalivajev/cmcs/pkg/io"
+	stdio "io"
+	"os"
+)
+
+const (
+	textFormat   = "text"
+	tsplibFormat = "tsplib"
+)
+
+// reads the instance from the file in the given format
+func readInstance(location, format string) (*gtsp.Instance, error) {
+	switch format {
+	case textFormat:
+		return io.ImportInstance(location)
+	case tsplibFormat:
+		return io.ImportTSPLIB(location)
+	}
+	return nil, fmt.Errorf("unknown instance format %q, expected %q or %q", format, textFormat, tsplibFormat)
+}
+
+// checks the instance format before any output file is created for it
+func checkInstanceFormat(format string) error {
+	if format != textFormat && format != tsplibFormat {
+		return fmt.Errorf("unknown instance format %q, expected %q or %q", format, textFormat, tsplibFormat)
+	}
+	return nil
+}
+
+// writes the instance in the given format. the options apply to the text format only
+func writeInstance(w stdio.Writer, instance *gtsp.Instance, format string, options ...io.WriteOption) error {
+	switch format {
+	case textFormat:
+		return io.WriteInstance(w, instance, options...)
+	case tsplibFormat:
+		return io.WriteTSPLIB(w, instance)
+	}
+	return fmt.Errorf("unknown instance format %q, expected %q or %q", format, textFormat, tsplibFormat)
+}
+
+// calls write with the file at the given location, or with the fallback
+// writer if no location is given
+func writeOutput(location string, fallback stdio.Writer, write func(w stdio.Writer) error) (err error) {
+	if location == "" {
+		return write(fallback)
+	}
+
+	f, err := os.Create(location)
+	if err != nil {
+		return err
+	}
+
+	// close file on exit and check for its returned error
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return write(f)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/olegnalivajev/cmcs/pkg/io"
+	"github.com/olegnalivajev/cmcs/pkg/render"
+	"github.com/spf13/cobra"
+	stdio "io"
+	"path/filepath"
+	"strings"
+)
+
+var plotFlags struct {
+	format string
+	out    string
+	image  string
+	size   int
+	radius int
+}
+
+var plotCmd = &cobra.Command{
+	Use:   "plot <instance> [solution]",
+	Short: "Draw an instance and, optionally, a solution tour",
+	Long: `Draws the nodes of the instance coloured by their clusters, with the tour of
+the solution on top of them if one is given. The instance has to carry node
+coordinates. The picture is written to --out as SVG or PNG, chosen by --image or
+by the extension of the output file, or to the standard output as SVG.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		instance, err := readInstance(args[0], plotFlags.format)
+		if err != nil {
+			return err
+		}
+
+		var solution *gtsp.Solution
+		if len(args) == 2 {
+			solution, err = io.ImportSolution(args[1], instance)
+			if err != nil {
+				return fmt.Errorf("invalid solution: %v", err)
+			}
+		}
+
+		// the image format follows the extension of the output file, unless given explicitly
+
+		image := plotFlags.image
+		if image == "" {
+			image = strings.TrimPrefix(strings.ToLower(filepath.Ext(plotFlags.out)), ".")
+			if image != "png" {
+				image = "svg"
+			}
+		}
+
+		var draw func(w stdio.Writer, instance *gtsp.Instance, solution *gtsp.Solution, options render.Options) error
+		switch image {
+		case "svg":
+			draw = render.WriteSVG
+		case "png":
+			draw = render.WritePNG
+		default:
+			return fmt.Errorf("unknown image format %q, expected svg or png", image)
+		}
+
+		// the picture is drawn before the output file is created, so that
+		// a failure doesn't leave an empty file behind
+
+		var picture bytes.Buffer
+		options := render.Options{Size: plotFlags.size, NodeRadius: plotFlags.radius}
+		if err := draw(&picture, instance, solution, options); err != nil {
+			return err
+		}
+
+		return writeOutput(plotFlags.out, cmd.OutOrStdout(), func(w stdio.Writer) error {
+			_, err := picture.WriteTo(w)
+			return err
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(plotCmd)
+
+	defaults := render.DefaultOptions()
+	flags := plotCmd.Flags()
+	flags.StringVar(&plotFlags.format, "format", textFormat, "instance format, text or tsplib")
+	flags.StringVar(&plotFlags.out, "out", "", "output file for the picture, standard output if empty")
+	flags.StringVar(&plotFlags.image, "image", "", "image format, svg or png, taken from the --out extension if empty")
+	flags.IntVar(&plotFlags.size, "size", defaults.Size, "size of the longer side of the picture in pixels")
+	flags.IntVar(&plotFlags.radius, "radius", defaults.NodeRadius, "radius of the nodes in pixels")
+}
@@ -2,22 +2,33 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg"
 	"github.com/spf13/cobra"
 	"os"
+	"time"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "cmcs",
 	Short: "CMCS",
-	Long: `More of CMCS`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("cmcs was ran")
-	},
+	Long: `Conditional Markov Chain Search for the Generalised Travelling Salesman Problem.
+Generates instances, solves them and evaluates solutions.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
+
+// creates the random number generator of the run seeded with the --seed flag,
+// or with the current time if the flag isn't set
+func newRand(cmd *cobra.Command, seed int64) *pkg.Rand {
+	if !cmd.Flags().Changed("seed") {
+		seed = time.Now().UTC().UnixNano()
+	}
+	return pkg.NewRand(seed)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/components"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/olegnalivajev/cmcs/pkg/io"
+	"github.com/spf13/cobra"
+	stdio "io"
+	"time"
+)
+
+var solveFlags struct {
+	timeLimit  time.Duration
+	iterations int
+	seed       int64
+	out        string
+	format     string
+	config     string
+}
+
+var solveCmd = &cobra.Command{
+	Use:   "solve <instance>",
+	Short: "Solve a GTSP instance with CMCS",
+	Long: `Runs the Conditional Markov Chain Search on the instance until the time or
+iteration limit is reached. The best solution found is written to --out, or to
+the standard output if no file is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rnd := newRand(cmd, solveFlags.seed)
+
+		instance, err := readInstance(args[0], solveFlags.format)
+		if err != nil {
+			return err
+		}
+
+		// distances of instances defined by coordinates are calculated
+		// only once, rather than on every lookup during the search
+
+		instance.ComputeDistances()
+
+		config := components.DefaultConfiguration()
+		if solveFlags.config != "" {
+			if config, err = readConfiguration(solveFlags.config); err != nil {
+				return fmt.Errorf("invalid configuration: %v", err)
+			}
+		}
+
+		search, err := components.NewCMCS(config, solveFlags.timeLimit, solveFlags.iterations)
+		if err != nil {
+			return err
+		}
+
+		best := search.Run(gtsp.GenerateSolution(instance, rnd), rnd)
+		fmt.Fprintf(cmd.ErrOrStderr(), "best distance: %d\n", best.Distance)
+
+		return writeOutput(solveFlags.out, cmd.OutOrStdout(), func(w stdio.Writer) error {
+			return io.WriteSolution(w, best)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(solveCmd)
+
+	flags := solveCmd.Flags()
+	flags.DurationVar(&solveFlags.timeLimit, "time", 10*time.Second, "time limit of the search, 0 for no limit")
+	flags.IntVar(&solveFlags.iterations, "iterations", 0, "maximum number of components applied, 0 for no limit")
+	flags.Int64Var(&solveFlags.seed, "seed", 0, "seed of the random number generator, current time if not set")
+	flags.StringVar(&solveFlags.out, "out", "", "output file for the solution, standard output if empty")
+	flags.StringVar(&solveFlags.format, "format", textFormat, "instance format, text or tsplib")
+	flags.StringVar(&solveFlags.config, "config", "", "CMCS configuration file in YAML or JSON, the default one if empty")
+}
@@ -2,34 +2,56 @@ package pkg
 
 import (
 	"math/rand"
-	"time"
 )
 
-var (
-	Seed int64
-)
-
-// defaults the seed of our Rand Number Generator to 1
-func init() {
-	InitialiseRandomNumberGenerator(time.Now().UTC().UnixNano())
+// Rand is a source of random numbers owned by a single run. runs with their own
+// Rand seeded with the same value produce the same results, even concurrently
+type Rand struct {
+	seed   int64
+	source *rand.Rand
 }
 
-// you can manually change the seed of the generator.
+// you can manually set the seed of the generator.
 // useful for testing purposes / when the goal is to achieve
 // the same result on re-runs
-func InitialiseRandomNumberGenerator(s int64) {
-	Seed = s
-	rand.Seed(Seed)
+func NewRand(seed int64) *Rand {
+	return &Rand{
+		seed:   seed,
+		source: rand.New(rand.NewSource(seed)), //nolint:gosec
+	}
+}
+
+func (r *Rand) Seed() int64 {
+	return r.seed
 }
 
-func GetRandomInteger(limit int) int {
+// Split returns a new generator whose stream is independent of this one. the seed of
+// the new generator is drawn from this generator, so splitting is deterministic
+func (r *Rand) Split() *Rand {
+	return NewRand(int64(mix(uint64(r.source.Int63()))))
+}
+
+func (r *Rand) GetRandomInteger(limit int) int {
 	if limit == 0 {
 		return 0
 	}
-	return rand.Intn(limit) //nolint:gosec
+	return r.source.Intn(limit)
 }
 
-func GetRandomIntegerInRange(lower, upper int) int {
-	return GetRandomInteger(upper-lower)+lower
+func (r *Rand) GetRandomIntegerInRange(lower, upper int) int {
+	return r.GetRandomInteger(upper-lower) + lower
 }
 
+// returns a random float in [0.0, 1.0)
+func (r *Rand) GetRandomFloat() float64 {
+	return r.source.Float64()
+}
+
+// splitmix64 finaliser, scatters consecutive values across the whole seed space
+// so that streams of split generators don't start from correlated seeds
+func mix(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
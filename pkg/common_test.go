@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRand_SameSeedSameSequence(t *testing.T) {
+	r1 := NewRand(42)
+	r2 := NewRand(42)
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, r1.GetRandomInteger(1000), r2.GetRandomInteger(1000))
+	}
+	assert.EqualValues(t, 42, r1.Seed())
+}
+
+func TestRand_Split(t *testing.T) {
+	parent1 := NewRand(7)
+	parent2 := NewRand(7)
+
+	// splitting is deterministic
+
+	child1 := parent1.Split()
+	child2 := parent2.Split()
+	assert.Equal(t, child1.Seed(), child2.Seed())
+	assert.Equal(t, child1.GetRandomInteger(1000), child2.GetRandomInteger(1000))
+
+	// consecutive splits give different streams
+
+	assert.NotEqual(t, child1.Seed(), parent1.Split().Seed())
+	assert.NotEqual(t, parent1.Seed(), child1.Seed())
+}
+
+func TestRand_GetRandomIntegerInRange(t *testing.T) {
+	r := NewRand(1)
+	for i := 0; i < 100; i++ {
+		v := r.GetRandomIntegerInRange(5, 10)
+		assert.True(t, v >= 5 && v < 10)
+	}
+	assert.Equal(t, 0, r.GetRandomInteger(0))
+}
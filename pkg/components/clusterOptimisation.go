@@ -1 +1,129 @@
 package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// ClusterOptimisation keeps the order of clusters fixed and selects the best
+// vertex in every cluster by finding the shortest path through the layered graph
+// of clusters, starting and ending at the same vertex of the smallest cluster
+type ClusterOptimisation struct{}
+
+func NewClusterOptimisation() *ClusterOptimisation {
+	return &ClusterOptimisation{}
+}
+
+func (c *ClusterOptimisation) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	inst := solution.Instance
+
+	// the cycle is cut open at the smallest cluster, as the shortest path has
+	// to be computed once for every vertex of the first layer
+
+	order := make([]int, 0, inst.ClusterCount)
+	first := inst.GetMinCluster()
+	order = append(order, first)
+	for cluster := solution.NextCluster[first]; cluster != first; cluster = solution.NextCluster[cluster] {
+		order = append(order, cluster)
+	}
+
+	if len(order) == 1 {
+		return 0
+	}
+
+	bestDistance := solution.Distance
+	var bestVertices []int
+
+	for _, start := range inst.Cluster(first) {
+		distance, vertices := shortestCycle(solution, order, start)
+		if distance < bestDistance {
+			bestDistance = distance
+			bestVertices = vertices
+		}
+	}
+
+	// the cluster order is optimal for the current vertices as well, so
+	// there's nothing to do unless a strictly shorter cycle was found
+
+	if bestVertices == nil {
+		return 0
+	}
+
+	gain := solution.Distance - bestDistance
+	for i, cluster := range order {
+		solution.Vertices[cluster] = bestVertices[i]
+	}
+	solution.Distance = bestDistance
+	return gain
+}
+
+var clusterOptimisationSpec = &ComponentSpec{
+	Name: "cluster-optimisation",
+	build: func(p Parameters) Component {
+		return NewClusterOptimisation()
+	},
+}
+
+func (c *ClusterOptimisation) getSpec() *ComponentSpec {
+	return clusterOptimisationSpec
+}
+
+func (c *ClusterOptimisation) getParameters() Parameters {
+	return Parameters{}
+}
+
+// computes the shortest cycle going through the clusters in the given order, which
+// starts and ends at the `start` vertex. returns its length and the selected vertex
+// for each cluster in the order
+func shortestCycle(solution *gtsp.Solution, order []int, start int) (int, []int) {
+	inst := solution.Instance
+
+	// dist[i] is the length of the shortest path from `start` to the i-th vertex of
+	// the current layer, pred[l][i] is the index of the vertex in layer l-1 preceding
+	// the i-th vertex of layer l on that path
+
+	pred := make([][]int, len(order))
+	dist := []int{0}
+	prevLayer := []int{start}
+
+	for l := 1; l < len(order); l++ {
+		layer := inst.Cluster(order[l])
+		next := make([]int, len(layer))
+		pred[l] = make([]int, len(layer))
+
+		for i, to := range layer {
+			next[i] = int(^uint(0) >> 1)
+			for j, from := range prevLayer {
+				d := dist[j] + inst.GetDistance(from, to)
+				if d < next[i] {
+					next[i] = d
+					pred[l][i] = j
+				}
+			}
+		}
+
+		dist = next
+		prevLayer = layer
+	}
+
+	// close the cycle by returning to the start vertex
+
+	best := 0
+	total := int(^uint(0) >> 1)
+	for i, from := range prevLayer {
+		d := dist[i] + inst.GetDistance(from, start)
+		if d < total {
+			total = d
+			best = i
+		}
+	}
+
+	vertices := make([]int, len(order))
+	vertices[0] = start
+	for l := len(order) - 1; l > 0; l-- {
+		vertices[l] = inst.Cluster(order[l])[best]
+		best = pred[l][best]
+	}
+
+	return total, vertices
+}
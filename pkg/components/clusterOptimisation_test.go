@@ -0,0 +1,198 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/exact"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClusterOptimisation_Apply(t *testing.T) {
+	inst, err := gtsp.NewInstance(6, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0, 1},
+		1: {2, 3},
+		2: {4, 5},
+	})
+
+	inst.Distances = [][]int{
+		{0, 9, 1, 9, 9, 1},
+		{9, 0, 9, 9, 9, 9},
+		{1, 9, 0, 9, 1, 9},
+		{9, 9, 9, 0, 9, 9},
+		{9, 9, 1, 9, 0, 1},
+		{1, 9, 9, 9, 1, 0},
+	}
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	solution.PrevCluster = []int{2, 0, 1}
+	solution.NextCluster = []int{1, 2, 0}
+	solution.Vertices = []int{1, 3, 5}
+	solution.CalculateDistance()
+
+	assert.Equal(t, 27, solution.Distance)
+
+	// the only short cycle is 0 -> 2 -> 4 -> 0, with the length of 1 + 1 + 9 = 11
+	// or 0 -> 2 -> 5 -> 0, with the length of 1 + 9 + 1 = 11
+
+	gain := NewClusterOptimisation().apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 16, gain)
+	assert.Equal(t, 11, solution.Distance)
+	assert.Equal(t, 0, solution.Vertices[0])
+	assert.Equal(t, 2, solution.Vertices[1])
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestClusterOptimisation_ApplyAsymmetric(t *testing.T) {
+	inst, err := gtsp.NewInstance(5, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.Symmetric = false
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1, 2},
+		2: {3, 4},
+	})
+
+	// only the cycle 0 -> 1 -> 3 -> 0 is short, and only in this direction
+
+	inst.Distances = [][]int{
+		{0, 1, 5, 9, 9},
+		{9, 0, 9, 1, 9},
+		{9, 9, 0, 9, 9},
+		{1, 9, 9, 0, 9},
+		{9, 9, 9, 9, 0},
+	}
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	solution.PrevCluster = []int{2, 0, 1}
+	solution.NextCluster = []int{1, 2, 0}
+	solution.Vertices = []int{0, 2, 4}
+	solution.CalculateDistance()
+
+	assert.Equal(t, 23, solution.Distance)
+
+	gain := NewClusterOptimisation().apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 20, gain)
+	assert.Equal(t, 3, solution.Distance)
+	assert.Equal(t, []int{0, 1, 3}, solution.Vertices)
+}
+
+func TestClusterOptimisation_ApplyTies(t *testing.T) {
+	inst, err := gtsp.NewInstance(6, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0, 1},
+		1: {2, 3},
+		2: {4, 5},
+	})
+
+	// the clusters are of the same size, and both 0 -> 3 -> 4 -> 0 and 1 -> 2 -> 5 -> 1
+	// are optimal. the cycle is cut open at the first cluster, so the search picks the
+	// former every time
+
+	inst.Distances = [][]int{
+		{0, 9, 9, 1, 1, 9},
+		{9, 0, 1, 9, 9, 1},
+		{9, 1, 0, 9, 9, 1},
+		{1, 9, 9, 0, 1, 9},
+		{1, 9, 9, 1, 0, 9},
+		{9, 1, 1, 9, 9, 0},
+	}
+
+	for i := 0; i < 100; i++ {
+		solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+		solution.PrevCluster = []int{2, 0, 1}
+		solution.NextCluster = []int{1, 2, 0}
+		solution.Vertices = []int{0, 2, 4}
+		solution.CalculateDistance()
+
+		gain := NewClusterOptimisation().apply(solution, pkg.NewRand(1))
+
+		assert.Equal(t, 16, gain)
+		assert.Equal(t, []int{0, 3, 4}, solution.Vertices)
+	}
+}
+
+func TestClusterOptimisation_ApplyIsOptimal(t *testing.T) {
+	inst, err := gtsp.NewInstance(12, 4, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	before := solution.Distance
+
+	gain := NewClusterOptimisation().apply(solution, pkg.NewRand(1))
+	after := solution.Distance
+
+	assert.Equal(t, before-after, gain)
+	assert.True(t, solution.IsFeasible())
+
+	// the distance has to be consistent with the tour
+
+	solution.CalculateDistance()
+	assert.Equal(t, after, solution.Distance)
+
+	// try every combination of vertices for the fixed cluster order,
+	// none of them can be shorter
+
+	assert.Equal(t, bruteForceVertices(solution, 0), after)
+}
+
+func TestClusterOptimisation_ApplyNoImprovement(t *testing.T) {
+	inst, err := gtsp.NewInstance(12, 4, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	co := NewClusterOptimisation()
+	co.apply(solution, pkg.NewRand(1))
+
+	// second application can't improve the solution any further
+
+	assert.Equal(t, 0, co.apply(solution, pkg.NewRand(1)))
+}
+
+func TestClusterOptimisation_ApplyReachesOptimum(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		inst, err := gtsp.NewInstance(40, 10, pkg.NewRand(seed), gtsp.Asymmetric(int(seed%2)*30))
+		assert.True(t, err == nil)
+
+		optimal, err := exact.Solve(inst)
+		assert.True(t, err == nil)
+
+		// given the optimal order of clusters, the best vertices
+		// are found from any starting selection
+
+		solution := optimal.DeepCopy()
+		for cluster := range solution.Vertices {
+			solution.SetVertex(cluster, inst.Cluster(cluster)[0])
+		}
+
+		NewClusterOptimisation().apply(solution, pkg.NewRand(seed))
+		assert.Equal(t, optimal.Distance, solution.Distance, "seed %d", seed)
+	}
+}
+
+// returns the shortest distance across every vertex selection, starting at the given cluster
+func bruteForceVertices(solution *gtsp.Solution, cluster int) int {
+	if cluster == solution.Instance.ClusterCount {
+		s := solution.DeepCopy()
+		s.CalculateDistance()
+		return s.Distance
+	}
+	best := int(^uint(0) >> 1)
+	original := solution.Vertices[cluster]
+	for _, v := range solution.Instance.Cluster(cluster) {
+		solution.Vertices[cluster] = v
+		if d := bruteForceVertices(solution, cluster+1); d < best {
+			best = d
+		}
+	}
+	solution.Vertices[cluster] = original
+	return best
+}
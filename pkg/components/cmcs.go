@@ -0,0 +1,163 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"math"
+	"time"
+)
+
+const probabilityTolerance = 1e-6
+
+// Configuration of the Conditional Markov Chain Search. Success[i][j] is the probability
+// of applying component j after component i has improved the solution, Failure[i][j]
+// is the probability of applying component j after component i has failed to improve it
+type Configuration struct {
+	Components []Component
+	Success    [][]float64
+	Failure    [][]float64
+}
+
+// DefaultConfiguration alternates the hill climbers until none of them can improve
+// the solution, and then perturbs it with a random vertex mutation
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		Components: []Component{
+			NewInsertion(),
+			NewTwoOpt(),
+			NewClusterOptimisation(),
+			NewVertexMutation(RandomVertexMutation),
+		},
+		Success: [][]float64{
+			{0, 0, 1, 0},
+			{1, 0, 0, 0},
+			{1, 0, 0, 0},
+			{1, 0, 0, 0},
+		},
+		Failure: [][]float64{
+			{0, 1, 0, 0},
+			{0, 0, 1, 0},
+			{0, 0, 0, 1},
+			{1, 0, 0, 0},
+		},
+	}
+}
+
+type CMCS struct {
+	Configuration Configuration
+	TimeLimit     time.Duration // zero means no time limit
+	MaxIterations int           // zero means no iteration limit
+}
+
+func NewCMCS(config Configuration, timeLimit time.Duration, maxIterations int) (*CMCS, error) {
+	if timeLimit <= 0 && maxIterations <= 0 {
+		return nil, errors.New("either `time limit` or `max iterations` has to be set")
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &CMCS{
+		Configuration: config,
+		TimeLimit:     timeLimit,
+		MaxIterations: maxIterations,
+	}, nil
+}
+
+func (c *CMCS) Run(solution *gtsp.Solution, rnd *pkg.Rand) *gtsp.Solution {
+
+	// the chain walks over the components starting from the first one. the given
+	// solution is modified in place, while the best solution seen so far is kept
+	// as a separate copy and returned once the budget is exhausted. all the random
+	// decisions of the run, including those of components, are drawn from `rnd`
+
+	best := solution.DeepCopy()
+	start := time.Now()
+	current := 0
+
+	for i := 0; !c.exhausted(i, start); i++ {
+		gain := c.Configuration.Components[current].apply(solution, rnd)
+
+		if solution.Distance < best.Distance {
+			best = solution.DeepCopy()
+		}
+
+		// the next component is picked from the row of the current component,
+		// either in the success or in the failure matrix
+
+		if gain > 0 {
+			current = nextComponent(c.Configuration.Success[current], rnd)
+		} else {
+			current = nextComponent(c.Configuration.Failure[current], rnd)
+		}
+	}
+
+	return best
+}
+
+func (c *CMCS) exhausted(iteration int, start time.Time) bool {
+	if c.MaxIterations > 0 && iteration >= c.MaxIterations {
+		return true
+	}
+	return c.TimeLimit > 0 && time.Since(start) >= c.TimeLimit
+}
+
+func (config Configuration) validate() error {
+	n := len(config.Components)
+	if n == 0 {
+		return errors.New("configuration has no components")
+	}
+	for _, c := range config.Components {
+		if _, err := c.getSpec().validate(c.getParameters()); err != nil {
+			return err
+		}
+	}
+	if err := validateMatrix("success", config.Success, n); err != nil {
+		return err
+	}
+	return validateMatrix("failure", config.Failure, n)
+}
+
+func validateMatrix(name string, matrix [][]float64, n int) error {
+	if len(matrix) != n {
+		return fmt.Errorf("%s matrix has %d rows, expected %d", name, len(matrix), n)
+	}
+	for i, row := range matrix {
+		if len(row) != n {
+			return fmt.Errorf("%s matrix row %d has %d columns, expected %d", name, i, len(row), n)
+		}
+		sum := 0.0
+		for j, p := range row {
+			if p < 0 {
+				return fmt.Errorf("%s matrix has a negative probability at [%d][%d]", name, i, j)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > probabilityTolerance {
+			return fmt.Errorf("%s matrix row %d sums up to %f, expected 1", name, i, sum)
+		}
+	}
+	return nil
+}
+
+// picks a component index according to the given probability distribution
+func nextComponent(row []float64, rnd *pkg.Rand) int {
+	r := rnd.GetRandomFloat()
+	last := 0
+	for i, p := range row {
+		if p == 0 {
+			continue
+		}
+		if r < p {
+			return i
+		}
+		r -= p
+		last = i
+	}
+
+	// rounding errors can leave a tiny remainder, it belongs to the last
+	// component with a non-zero probability
+
+	return last
+}
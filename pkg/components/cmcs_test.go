@@ -0,0 +1,197 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/exact"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stub component that changes the distance by a fixed amount on every call
+type stubComponent struct {
+	gain  int
+	calls int
+}
+
+func (c *stubComponent) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	c.calls++
+	solution.Distance -= c.gain
+	return c.gain
+}
+
+func (c *stubComponent) getSpec() *ComponentSpec {
+	return &ComponentSpec{Name: "stub"}
+}
+
+func (c *stubComponent) getParameters() Parameters {
+	return Parameters{}
+}
+
+func TestNewCMCS_NoBudget(t *testing.T) {
+	config := Configuration{
+		Components: []Component{&stubComponent{}},
+		Success:    [][]float64{{1}},
+		Failure:    [][]float64{{1}},
+	}
+	_, err := NewCMCS(config, 0, 0)
+	assert.Equal(t, "either `time limit` or `max iterations` has to be set", err.Error())
+}
+
+func TestNewCMCS_InvalidMatrix(t *testing.T) {
+	config := Configuration{
+		Components: []Component{&stubComponent{}, &stubComponent{}},
+		Success:    [][]float64{{0.5, 0.5}, {0.3, 0.3}},
+		Failure:    [][]float64{{1, 0}, {0, 1}},
+	}
+	_, err := NewCMCS(config, 0, 10)
+	assert.Equal(t, "success matrix row 1 sums up to 0.600000, expected 1", err.Error())
+
+	config.Success = [][]float64{{1, 0}}
+	_, err = NewCMCS(config, 0, 10)
+	assert.Equal(t, "success matrix has 1 rows, expected 2", err.Error())
+}
+
+func TestCMCS_Run(t *testing.T) {
+	inst, err := gtsp.NewInstance(20, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	initial := solution.Distance
+
+	// component 0 always improves and hands over to component 1,
+	// component 1 always fails and hands back to component 0
+
+	improving := &stubComponent{gain: 1}
+	failing := &stubComponent{gain: -1}
+
+	config := Configuration{
+		Components: []Component{improving, failing},
+		Success:    [][]float64{{0, 1}, {0, 1}},
+		Failure:    [][]float64{{1, 0}, {1, 0}},
+	}
+	search, err := NewCMCS(config, 0, 10)
+	assert.True(t, err == nil)
+
+	best := search.Run(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 5, improving.calls)
+	assert.Equal(t, 5, failing.calls)
+
+	// every improvement is undone by the failing component, so the best
+	// solution is the one right after the first improvement
+
+	assert.Equal(t, initial-1, best.Distance)
+	assert.Equal(t, initial, solution.Distance)
+}
+
+func TestCMCS_RunTimeLimit(t *testing.T) {
+	inst, err := gtsp.NewInstance(20, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	config := Configuration{
+		Components: []Component{&stubComponent{}},
+		Success:    [][]float64{{1}},
+		Failure:    [][]float64{{1}},
+	}
+	search, err := NewCMCS(config, 10*time.Millisecond, 0)
+	assert.True(t, err == nil)
+
+	start := time.Now()
+	search.Run(solution, pkg.NewRand(1))
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
+
+func TestCMCS_RunIsReproducible(t *testing.T) {
+	inst, err := gtsp.NewInstance(80, 16, pkg.NewRand(3))
+	assert.True(t, err == nil)
+
+	search, err := NewCMCS(DefaultConfiguration(), 0, 200)
+	assert.True(t, err == nil)
+
+	// two concurrent runs with the same seed have to find the same solution
+
+	results := make(chan *gtsp.Solution, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			rnd := pkg.NewRand(11)
+			results <- search.Run(gtsp.GenerateSolution(inst, rnd), rnd)
+		}()
+	}
+
+	first := <-results
+	second := <-results
+	assert.Equal(t, first.Distance, second.Distance)
+	assert.Equal(t, first.Tour(), second.Tour())
+}
+
+func Test_NextComponent(t *testing.T) {
+	assert.Equal(t, 2, nextComponent([]float64{0, 0, 1}, pkg.NewRand(1)))
+	assert.Equal(t, 0, nextComponent([]float64{1, 0, 0}, pkg.NewRand(1)))
+}
+
+// the best solution is snapshotted on every improvement, which
+// has to stay cheap on large instances
+func BenchmarkCMCS_Run(b *testing.B) {
+	inst, err := gtsp.NewInstance(1000, 200, pkg.NewRand(1))
+	assert.True(b, err == nil)
+
+	search, err := NewCMCS(DefaultConfiguration(), 0, 50)
+	assert.True(b, err == nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		search.Run(gtsp.GenerateSolution(inst, pkg.NewRand(int64(i))), pkg.NewRand(int64(i)))
+	}
+}
+
+func TestCMCS_RunReachesOptimum(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		for _, asymmetry := range []int{0, 30} {
+			inst, err := gtsp.NewInstance(30, 10, pkg.NewRand(seed), gtsp.Asymmetric(asymmetry))
+			assert.True(t, err == nil)
+
+			optimal, err := exact.Solve(inst)
+			assert.True(t, err == nil)
+
+			search, err := NewCMCS(DefaultConfiguration(), 0, 10000)
+			assert.True(t, err == nil)
+
+			best := search.Run(gtsp.GenerateSolution(inst, pkg.NewRand(seed)), pkg.NewRand(seed))
+			assert.Equal(t, optimal.Distance, best.Distance, "seed %d, asymmetry %d", seed, asymmetry)
+		}
+	}
+}
+
+// every component has to cope with an instance of a single cluster
+func TestCMCS_RunSingleCluster(t *testing.T) {
+	inst, err := gtsp.NewInstance(5, 1, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	var config Configuration
+	for _, spec := range Specs() {
+		c, err := NewComponent(spec.Name, nil)
+		assert.True(t, err == nil)
+		config.Components = append(config.Components, c)
+	}
+
+	// the components are applied one after another, in a cycle
+
+	n := len(config.Components)
+	for i := 0; i < n; i++ {
+		row := make([]float64, n)
+		row[(i+1)%n] = 1
+		config.Success = append(config.Success, row)
+		config.Failure = append(config.Failure, row)
+	}
+
+	search, err := NewCMCS(config, 0, 3*n)
+	assert.True(t, err == nil)
+
+	best := search.Run(gtsp.GenerateSolution(inst, pkg.NewRand(1)), pkg.NewRand(1))
+	assert.True(t, best.IsFeasible())
+	assert.Equal(t, 0, best.Distance)
+}
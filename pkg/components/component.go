@@ -1,10 +1,47 @@
 package components
 
-type Parameters struct {
-
-}
+import (
+	"errors"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
 
+// Component is a single move of the search. apply modifies the solution in place
+// and returns the improvement it achieved, i.e. old distance - new distance.
+// positive value means the component succeeded, zero or negative means it failed.
+// components hold no state of a run, every random decision is drawn from `rnd`.
+// getSpec declares the tunables of the component, and getParameters their values.
+// the values have to be valid by the spec, NewCMCS rejects components whose values
+// aren't, and apply doesn't check them again
 type Component interface {
-	apply() int
+	apply(solution *gtsp.Solution, rnd *pkg.Rand) int
+	getSpec() *ComponentSpec
 	getParameters() Parameters
 }
+
+// checks the strength of a perturbation, which has to change the solution at least once
+func checkStrength(strength int) error {
+	if strength < 1 {
+		return errors.New("`strength` expected to be at least 1")
+	}
+	return nil
+}
+
+// picks `count` distinct random clusters, at most all of them
+func randomClusters(clusterCount, count int, rnd *pkg.Rand) []int {
+	if count > clusterCount {
+		count = clusterCount
+	}
+
+	// partial Fisher–Yates shuffle, only the first `count` positions are drawn
+
+	clusters := make([]int, clusterCount)
+	for i := range clusters {
+		clusters[i] = i
+	}
+	for i := 0; i < count; i++ {
+		j := i + rnd.GetRandomInteger(clusterCount-i)
+		clusters[i], clusters[j] = clusters[j], clusters[i]
+	}
+	return clusters[:count]
+}
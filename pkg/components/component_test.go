@@ -0,0 +1,202 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/exact"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// no hill climber can improve an optimal solution, nor may it report a gain
+func TestComponents_CannotImproveOptimum(t *testing.T) {
+	climbers := []Component{
+		NewInsertion(),
+		NewBestVertexInsertion(),
+		NewTwoOpt(),
+		NewClusterOptimisation(),
+		NewVertexMutation(BestVertexMutation),
+		NewOrOpt(1, 3, true),
+		NewSwap(BestImprovementSwap),
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		inst, err := gtsp.NewInstance(30, 8, pkg.NewRand(seed), gtsp.Asymmetric(int(seed%2)*30))
+		assert.True(t, err == nil)
+
+		optimal, err := exact.Solve(inst)
+		assert.True(t, err == nil)
+
+		for _, c := range climbers {
+			solution := optimal.DeepCopy()
+			assert.Equal(t, 0, c.apply(solution, pkg.NewRand(seed)), "%T, seed %d", c, seed)
+			assert.Equal(t, optimal.Distance, solution.Distance, "%T, seed %d", c, seed)
+		}
+	}
+}
+
+func TestPerturbations_RejectNoStrength(t *testing.T) {
+	constructors := map[string]func(strength int) (Component, error){
+		"double-bridge":    func(strength int) (Component, error) { return NewDoubleBridge(strength) },
+		"random-insertion": func(strength int) (Component, error) { return NewRandomInsertion(strength) },
+		"vertex-mutation":  func(strength int) (Component, error) { return NewRandomVertexMutation(strength) },
+		"restart":          func(strength int) (Component, error) { return NewRestart(strength) },
+	}
+
+	for name, constructor := range constructors {
+		for _, strength := range []int{0, -1} {
+			_, err := constructor(strength)
+			if assert.True(t, err != nil, "%s, strength %d", name, strength) {
+				assert.Equal(t, "`strength` expected to be at least 1", err.Error())
+			}
+		}
+	}
+}
+
+// perturbations keep the solution feasible, its distance consistent with the tour, and
+// report the change of the distance, whatever it is. the same seed gives the same result
+func TestPerturbations_AreConsistent(t *testing.T) {
+	perturbations := []Component{
+		must(NewDoubleBridge(3)),
+		must(NewRandomInsertion(4)),
+		must(NewRandomVertexMutation(5)),
+		must(NewRestart(2)),
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		inst, err := gtsp.NewInstance(40, 10, pkg.NewRand(seed), gtsp.Asymmetric(int(seed%2)*30))
+		assert.True(t, err == nil)
+
+		initial := gtsp.GenerateSolution(inst, pkg.NewRand(seed))
+
+		for _, c := range perturbations {
+			solution := initial.DeepCopy()
+			gain := c.apply(solution, pkg.NewRand(seed))
+			after := solution.Distance
+
+			assert.True(t, solution.IsFeasible(), "%T, seed %d", c, seed)
+			assert.Equal(t, initial.Distance-after, gain, "%T, seed %d", c, seed)
+
+			solution.CalculateDistance()
+			assert.Equal(t, after, solution.Distance, "%T, seed %d", c, seed)
+
+			again := initial.DeepCopy()
+			c.apply(again, pkg.NewRand(seed))
+			assert.Equal(t, solution.Tour(), again.Tour(), "%T, seed %d", c, seed)
+		}
+	}
+}
+
+// every hill climber reports the change of the distance, keeps the solution consistent,
+// and stops at a local optimum, where no move of its neighbourhood improves the solution
+func TestHillClimbers_ReachLocalOptimum(t *testing.T) {
+	climbers := []struct {
+		name     string
+		climber  Component
+		improves func(solution *gtsp.Solution) bool // tells whether any move improves the solution
+	}{
+		{"insertion", NewInsertion(), insertionImproves},
+		{"best vertex insertion", NewBestVertexInsertion(), bestVertexInsertionImproves},
+		{"or-opt", NewOrOpt(2, 3, false), orOptImproves(2, 3, false)},
+		{"or-opt with reversal", NewOrOpt(2, 3, true), orOptImproves(2, 3, true)},
+		{"first improvement swap", NewSwap(FirstImprovementSwap), swapImproves},
+		{"best improvement swap", NewSwap(BestImprovementSwap), swapImproves},
+	}
+
+	for _, asymmetry := range []int{0, 50} {
+		inst, err := gtsp.NewInstance(40, 10, pkg.NewRand(1), gtsp.Asymmetric(asymmetry))
+		assert.True(t, err == nil)
+
+		for _, c := range climbers {
+			solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+			before := solution.Distance
+
+			// a random solution is far from a local optimum, which also tells the check works
+
+			assert.True(t, c.improves(solution), "%s, asymmetry %d", c.name, asymmetry)
+
+			gain := c.climber.apply(solution, pkg.NewRand(1))
+			after := solution.Distance
+
+			assert.Equal(t, before-after, gain, "%s, asymmetry %d", c.name, asymmetry)
+			assert.True(t, gain > 0, "%s, asymmetry %d", c.name, asymmetry)
+			assert.True(t, solution.IsFeasible(), "%s, asymmetry %d", c.name, asymmetry)
+
+			solution.CalculateDistance()
+			assert.Equal(t, after, solution.Distance, "%s, asymmetry %d", c.name, asymmetry)
+
+			assert.False(t, c.improves(solution), "%s, asymmetry %d", c.name, asymmetry)
+			assert.Equal(t, 0, c.climber.apply(solution, pkg.NewRand(1)), "%s, asymmetry %d", c.name, asymmetry)
+		}
+	}
+}
+
+func insertionImproves(solution *gtsp.Solution) bool {
+	n := solution.Instance.ClusterCount
+	for cluster := 0; cluster < n; cluster++ {
+		for position := 0; position < n; position++ {
+			if solution.InsertClusterDelta(cluster, position) < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bestVertexInsertionImproves(solution *gtsp.Solution) bool {
+	n := solution.Instance.ClusterCount
+	for cluster := 0; cluster < n; cluster++ {
+		for position := 0; position < n; position++ {
+			for _, v := range solution.Instance.Cluster(cluster) {
+				moved := solution.DeepCopy()
+				moved.SetVertex(cluster, v)
+				moved.InsertCluster(cluster, position)
+				if moved.Distance < solution.Distance {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func orOptImproves(minLength, maxLength int, reversal bool) func(solution *gtsp.Solution) bool {
+	return func(solution *gtsp.Solution) bool {
+		n := solution.Instance.ClusterCount
+		for length := minLength; length <= maxLength; length++ {
+			for first := 0; first < n; first++ {
+				last := first
+				segment := map[int]bool{first: true}
+				for i := 1; i < length; i++ {
+					last = solution.NextCluster[last]
+					segment[last] = true
+				}
+				for target := 0; target < n; target++ {
+					if segment[target] {
+						continue
+					}
+					for _, reversed := range []bool{false, reversal} {
+						moved := solution.DeepCopy()
+						moved.MoveSegment(first, last, target, reversed)
+						if moved.Distance < solution.Distance {
+							return true
+						}
+					}
+				}
+			}
+		}
+		return false
+	}
+}
+
+func swapImproves(solution *gtsp.Solution) bool {
+	n := solution.Instance.ClusterCount
+	for a := 0; a < n; a++ {
+		for b := 0; b < n; b++ {
+			if solution.SwapClustersDelta(a, b) < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package components
+
+import (
+	"encoding/json"
+)
+
+// ComponentConfig is a component as it's written in a configuration file
+type ComponentConfig struct {
+	Name       string     `json:"name" yaml:"name"`
+	Parameters Parameters `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// form of the configuration in JSON and YAML files. components are identified
+// by the names of their specs, and built with NewComponent when read
+type configurationFile struct {
+	Components []ComponentConfig `json:"components" yaml:"components"`
+	Success    [][]float64       `json:"success" yaml:"success"`
+	Failure    [][]float64       `json:"failure" yaml:"failure"`
+}
+
+func (config Configuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(config.toFile())
+}
+
+func (config *Configuration) UnmarshalJSON(data []byte) error {
+	var file configurationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	return config.fromFile(file)
+}
+
+func (config Configuration) MarshalYAML() (interface{}, error) {
+	return config.toFile(), nil
+}
+
+func (config *Configuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var file configurationFile
+	if err := unmarshal(&file); err != nil {
+		return err
+	}
+	return config.fromFile(file)
+}
+
+func (config Configuration) toFile() configurationFile {
+	file := configurationFile{Success: config.Success, Failure: config.Failure}
+	for _, c := range config.Components {
+		file.Components = append(file.Components, ComponentConfig{
+			Name:       c.getSpec().Name,
+			Parameters: c.getParameters(),
+		})
+	}
+	return file
+}
+
+// builds the components of the file. the matrices are checked once the search
+// is created, like those of any other configuration
+func (config *Configuration) fromFile(file configurationFile) error {
+	components := make([]Component, 0, len(file.Components))
+	for _, c := range file.Components {
+		component, err := NewComponent(c.Name, c.Parameters)
+		if err != nil {
+			return err
+		}
+		components = append(components, component)
+	}
+
+	config.Components = components
+	config.Success = file.Success
+	config.Failure = file.Failure
+	return nil
+}
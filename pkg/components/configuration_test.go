@@ -0,0 +1,80 @@
+package components
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func perturbedConfiguration() Configuration {
+	return Configuration{
+		Components: []Component{
+			NewOrOpt(1, 2, false),
+			NewSwap(BestImprovementSwap),
+			must(NewRandomVertexMutation(3)),
+			must(NewDoubleBridge(2)),
+		},
+		Success: [][]float64{
+			{1, 0, 0, 0},
+			{1, 0, 0, 0},
+			{0.5, 0.5, 0, 0},
+			{1, 0, 0, 0},
+		},
+		Failure: [][]float64{
+			{0, 1, 0, 0},
+			{0, 0, 0.25, 0.75},
+			{1, 0, 0, 0},
+			{1, 0, 0, 0},
+		},
+	}
+}
+
+func TestConfiguration_JSON(t *testing.T) {
+	for _, config := range []Configuration{DefaultConfiguration(), perturbedConfiguration()} {
+		data, err := json.Marshal(config)
+		assert.True(t, err == nil)
+
+		var read Configuration
+		assert.True(t, json.Unmarshal(data, &read) == nil)
+		assert.Equal(t, config, read)
+	}
+}
+
+func TestConfiguration_YAML(t *testing.T) {
+	for _, config := range []Configuration{DefaultConfiguration(), perturbedConfiguration()} {
+		data, err := yaml.Marshal(config)
+		assert.True(t, err == nil)
+
+		var read Configuration
+		assert.True(t, yaml.Unmarshal(data, &read) == nil)
+		assert.Equal(t, config, read)
+	}
+}
+
+func TestConfiguration_Format(t *testing.T) {
+	config := Configuration{
+		Components: []Component{NewTwoOpt(), must(NewRestart(2))},
+		Success:    [][]float64{{0, 1}, {1, 0}},
+		Failure:    [][]float64{{0, 1}, {1, 0}},
+	}
+
+	// components without tunables are written without parameters
+
+	data, err := json.Marshal(config)
+	assert.True(t, err == nil)
+	assert.Equal(t, `{"components":[{"name":"two-opt"},{"name":"restart","parameters":{"strength":2}}],`+
+		`"success":[[0,1],[1,0]],"failure":[[0,1],[1,0]]}`, string(data))
+}
+
+func TestConfiguration_UnmarshalInvalid(t *testing.T) {
+	var config Configuration
+	err := yaml.Unmarshal([]byte("components:\n- name: or-opt\n  parameters:\n    max-length: -1\n"), &config)
+	assert.Equal(t, "component `or-opt`: parameter `max-length` is -1, expected at least 1", err.Error())
+
+	err = yaml.UnmarshalStrict([]byte("components:\n- name: or-opt\n  parameters:\n    min-length: 4\n    max-length: 2\n"), &config)
+	assert.Equal(t, "component `or-opt`: parameter `min-length` is 4, expected at most `max-length` 2", err.Error())
+
+	err = json.Unmarshal([]byte(`{"components":[{"name":"greedy"}]}`), &config)
+	assert.Equal(t, "unknown component `greedy`", err.Error())
+}
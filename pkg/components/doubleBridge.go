@@ -0,0 +1,69 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"sort"
+)
+
+// DoubleBridge is a perturbation which cuts the cluster order into four parts A B C D
+// at random, and reconnects them as A C B D. the move can't be undone by a single
+// 2-opt or insertion, which makes it a common kick out of local optima
+type DoubleBridge struct {
+	// number of double-bridge moves applied in a row, at least 1
+	Strength int
+}
+
+func NewDoubleBridge(strength int) (*DoubleBridge, error) {
+	if err := checkStrength(strength); err != nil {
+		return nil, err
+	}
+	return &DoubleBridge{Strength: strength}, nil
+}
+
+func (c *DoubleBridge) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	n := solution.Instance.ClusterCount
+
+	// every part has to contain at least one cluster
+
+	if n < 4 {
+		return 0
+	}
+
+	before := solution.Distance
+	order := make([]int, n)
+	for i := 0; i < c.Strength; i++ {
+		order[0] = 0
+		for j := 1; j < n; j++ {
+			order[j] = solution.NextCluster[order[j-1]]
+		}
+
+		// B starts at one of the cut positions and C ends right before another one,
+		// therefore moving B after the end of C swaps them
+
+		cuts := randomClusters(n-1, 3, rnd)
+		sort.Ints(cuts)
+		b, c, d := cuts[0]+1, cuts[1]+1, cuts[2]+1
+		solution.MoveSegment(order[b], order[c-1], order[d-1], false)
+	}
+	return before - solution.Distance
+}
+
+var doubleBridgeSpec = &ComponentSpec{
+	Name: "double-bridge",
+	Parameters: []ParameterSpec{
+		{Name: "strength", Type: IntParameter, Min: 1, Default: 1},
+	},
+	build: func(p Parameters) Component {
+		c, _ := NewDoubleBridge(p.getInt("strength")) // the strength is already validated
+		return c
+	},
+}
+
+func (c *DoubleBridge) getSpec() *ComponentSpec {
+	return doubleBridgeSpec
+}
+
+func (c *DoubleBridge) getParameters() Parameters {
+	return Parameters{"strength": c.Strength}
+}
@@ -0,0 +1,47 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDoubleBridge_Apply(t *testing.T) {
+	inst := lineInstance(t)
+	doubleBridge, err := NewDoubleBridge(1)
+	assert.True(t, err == nil)
+
+	for seed := int64(1); seed <= 20; seed++ {
+		solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+		setTour(solution, []int{0, 1, 2, 3, 4, 5})
+		next := append([]int(nil), solution.NextCluster...)
+
+		// A B C D becomes A C B D, which replaces exactly three edges
+
+		doubleBridge.apply(solution, pkg.NewRand(seed))
+
+		changed := 0
+		for cluster := range next {
+			if next[cluster] != solution.NextCluster[cluster] {
+				changed++
+			}
+		}
+		assert.Equal(t, 3, changed, "seed %d", seed)
+		assert.True(t, solution.IsFeasible())
+	}
+}
+
+func TestDoubleBridge_ApplyTooFewClusters(t *testing.T) {
+	inst, err := gtsp.NewInstance(9, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	doubleBridge, err := NewDoubleBridge(5)
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	tour := solution.Tour()
+
+	assert.Equal(t, 0, doubleBridge.apply(solution, pkg.NewRand(1)))
+	assert.Equal(t, tour, solution.Tour())
+}
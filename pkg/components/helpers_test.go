@@ -0,0 +1,46 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// instance of 6 single-vertex clusters placed on a line, where the distance is |i - j|
+func lineInstance(t *testing.T) *gtsp.Instance {
+	inst, err := gtsp.NewInstance(6, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	clusters := make(map[int][]int)
+	for i := 0; i < 6; i++ {
+		clusters[i] = []int{i}
+		for j := 0; j < 6; j++ {
+			inst.Distances[i][j] = i - j
+			if j > i {
+				inst.Distances[i][j] = j - i
+			}
+		}
+	}
+	inst.SetClusters(clusters)
+	return inst
+}
+
+// returns the component of a constructor which can fail, for the lists of components
+// of tests. the arguments of the constructors are always valid there
+func must(c Component, err error) Component {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// sets the order of clusters of the solution
+func setTour(solution *gtsp.Solution, tour []int) {
+	for i, cluster := range tour {
+		next := tour[(i+1)%len(tour)]
+		solution.NextCluster[cluster] = next
+		solution.PrevCluster[next] = cluster
+	}
+	solution.CalculateDistance()
+}
@@ -0,0 +1,129 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// Insertion is a hill climber which moves single clusters to a different position
+// in the tour, until no such move improves the solution
+type Insertion struct {
+	// selects the best vertex of the moved cluster for every position, rather
+	// than keeping its current vertex
+	BestVertex bool
+}
+
+func NewInsertion() *Insertion {
+	return &Insertion{}
+}
+
+func NewBestVertexInsertion() *Insertion {
+	return &Insertion{BestVertex: true}
+}
+
+func (c *Insertion) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+
+	// with fewer than 3 clusters every cluster order gives the same tour,
+	// though the vertex of a cluster can still be changed
+
+	if solution.Instance.ClusterCount < 3 && !(c.BestVertex && solution.Instance.ClusterCount == 2) {
+		return 0
+	}
+
+	gain := 0
+	for improved := true; improved; {
+		improved = false
+
+		// for each cluster find the position where it's the cheapest to insert it,
+		// and move it there if it shortens the tour
+
+		for cluster := 0; cluster < solution.Instance.ClusterCount; cluster++ {
+			if c.BestVertex {
+				if delta := insertBestVertex(solution, cluster); delta < 0 {
+					gain -= delta
+					improved = true
+				}
+				continue
+			}
+
+			bestDelta := 0
+			bestPosition := -1
+			for position := 0; position < solution.Instance.ClusterCount; position++ {
+				if delta := solution.InsertClusterDelta(cluster, position); delta < bestDelta {
+					bestDelta = delta
+					bestPosition = position
+				}
+			}
+
+			if bestPosition >= 0 {
+				solution.InsertCluster(cluster, bestPosition)
+				gain -= bestDelta
+				improved = true
+			}
+		}
+	}
+	return gain
+}
+
+var insertionSpec = &ComponentSpec{
+	Name: "insertion",
+	Parameters: []ParameterSpec{
+		{Name: "best-vertex", Type: BoolParameter, Default: false},
+	},
+	build: func(p Parameters) Component {
+		return &Insertion{BestVertex: p.getBool("best-vertex")}
+	},
+}
+
+func (c *Insertion) getSpec() *ComponentSpec {
+	return insertionSpec
+}
+
+func (c *Insertion) getParameters() Parameters {
+	return Parameters{"best-vertex": c.BestVertex}
+}
+
+// moves the cluster to the position and vertex where it's the cheapest to insert it,
+// if that shortens the tour. returns the change of the distance
+func insertBestVertex(solution *gtsp.Solution, cluster int) int {
+	inst := solution.Instance
+
+	before := solution.PrevCluster[cluster]
+	after := solution.NextCluster[cluster]
+	current := solution.Vertices[cluster]
+
+	// the cluster is taken out of the tour first, so that its current position
+	// is considered along with the others
+
+	removal := inst.GetDistance(solution.Vertices[before], solution.Vertices[after]) -
+		inst.GetDistance(solution.Vertices[before], current) -
+		inst.GetDistance(current, solution.Vertices[after])
+
+	bestDelta := 0
+	bestPosition, bestVertex := -1, current
+	for p := 0; p < inst.ClusterCount; p++ {
+		if p == cluster {
+			continue
+		}
+		q := solution.NextCluster[p]
+		if q == cluster {
+			q = after
+		}
+		pv, qv := solution.Vertices[p], solution.Vertices[q]
+
+		base := removal - inst.GetDistance(pv, qv)
+		for _, v := range inst.Cluster(cluster) {
+			if delta := base + inst.GetDistance(pv, v) + inst.GetDistance(v, qv); delta < bestDelta {
+				bestDelta = delta
+				bestPosition, bestVertex = p, v
+			}
+		}
+	}
+
+	if bestPosition < 0 {
+		return 0
+	}
+	solution.SetVertex(cluster, bestVertex)
+	solution.InsertCluster(cluster, bestPosition)
+	return bestDelta
+}
@@ -0,0 +1,112 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInsertion_Apply(t *testing.T) {
+	inst, err := gtsp.NewInstance(5, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1},
+		2: {2},
+		3: {3},
+		4: {4},
+	})
+
+	// points on a line, the optimal tour visits them in order and has the length of 8
+
+	inst.Distances = [][]int{
+		{0, 1, 2, 3, 4},
+		{1, 0, 1, 2, 3},
+		{2, 1, 0, 1, 2},
+		{3, 2, 1, 0, 1},
+		{4, 3, 2, 1, 0},
+	}
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	// 0 -> 2 -> 1 -> 3 -> 4 -> 0 = 2 + 1 + 2 + 1 + 4 = 10
+
+	solution.PrevCluster = []int{4, 2, 0, 1, 3}
+	solution.NextCluster = []int{2, 3, 1, 4, 0}
+	solution.CalculateDistance()
+	assert.Equal(t, 10, solution.Distance)
+
+	gain := NewInsertion().apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 2, gain)
+	assert.Equal(t, 8, solution.Distance)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestInsertion_ApplyBestVertex(t *testing.T) {
+	inst, err := gtsp.NewInstance(5, 4, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1},
+		2: {2},
+		3: {3, 4},
+	})
+
+	// clusters 0, 1 and 2 form a triangle of the side of 1. vertex 3 is far from all of
+	// them, while vertex 4 is close to 0 and 1 only
+
+	inst.Distances = [][]int{
+		{0, 1, 1, 5, 1},
+		{1, 0, 1, 5, 1},
+		{1, 1, 0, 5, 9},
+		{5, 5, 5, 0, 9},
+		{1, 1, 9, 9, 0},
+	}
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	solution.PrevCluster = []int{3, 0, 1, 2}
+	solution.NextCluster = []int{1, 2, 3, 0}
+	solution.Vertices = []int{0, 1, 2, 3}
+	solution.CalculateDistance()
+	assert.Equal(t, 12, solution.Distance)
+
+	// neither moving the cluster with its vertex, nor changing the vertex in place helps
+
+	assert.Equal(t, 0, NewInsertion().apply(solution, pkg.NewRand(1)))
+	assert.Equal(t, 0, NewVertexMutation(BestVertexMutation).apply(solution, pkg.NewRand(1)))
+
+	// 0 -> 4 -> 1 -> 2 -> 0 = 1 + 1 + 1 + 1 = 4
+
+	gain := NewBestVertexInsertion().apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 8, gain)
+	assert.Equal(t, 4, solution.Distance)
+	assert.Equal(t, 4, solution.Vertices[3])
+	assert.Equal(t, 3, solution.NextCluster[0])
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestInsertion_ApplyBestVertexTwoClusters(t *testing.T) {
+	inst, err := gtsp.NewInstance(10, 2, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// with 2 clusters only the vertices can change, one cluster at a time
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	before := solution.Distance
+
+	gain := NewBestVertexInsertion().apply(solution, pkg.NewRand(1))
+	assert.Equal(t, before-solution.Distance, gain)
+	assert.True(t, solution.IsFeasible())
+
+	for cluster := 0; cluster < 2; cluster++ {
+		for _, v := range inst.Cluster(cluster) {
+			changed := solution.DeepCopy()
+			assert.True(t, changed.SetVertex(cluster, v) <= 0)
+		}
+	}
+}
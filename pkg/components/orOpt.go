@@ -0,0 +1,151 @@
+package components
+
+import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// OrOpt is a hill climber which moves segments of consecutive clusters to a different
+// position in the tour, optionally reversing them, until no such move improves the solution
+type OrOpt struct {
+	MinLength int // shortest segment moved, at least 1
+	MaxLength int // longest segment moved
+	Reversal  bool
+}
+
+func NewOrOpt(minLength, maxLength int, reversal bool) *OrOpt {
+	return &OrOpt{MinLength: minLength, MaxLength: maxLength, Reversal: reversal}
+}
+
+func (c *OrOpt) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	n := solution.Instance.ClusterCount
+
+	// the rest of the tour needs at least 2 clusters to place the segment between
+
+	maxLength := c.MaxLength
+	if maxLength > n-2 {
+		maxLength = n - 2
+	}
+
+	gain := 0
+	inSegment := make([]bool, n)
+	segment := make([]int, 0, n)
+	for improved := true; improved; {
+		improved = false
+		for length := c.MinLength; length <= maxLength; length++ {
+			for first := 0; first < n; first++ {
+				if delta := c.moveBest(solution, first, length, segment, inSegment); delta < 0 {
+					gain -= delta
+					improved = true
+				}
+			}
+		}
+	}
+	return gain
+}
+
+// moves the segment of the given length starting at cluster `first` to its best position,
+// if that shortens the tour. returns the change of the distance. `segment` and `inSegment`
+// are buffers reused between calls, inSegment is left cleared
+func (c *OrOpt) moveBest(solution *gtsp.Solution, first, length int, segment []int, inSegment []bool) int {
+	inst := solution.Instance
+
+	segment = segment[:0]
+	for i, cluster := 0, first; i < length; i, cluster = i+1, solution.NextCluster[cluster] {
+		segment = append(segment, cluster)
+		inSegment[cluster] = true
+	}
+	last := segment[length-1]
+	defer func() {
+		for _, cluster := range segment {
+			inSegment[cluster] = false
+		}
+	}()
+
+	a := solution.Vertices[first]
+	b := solution.Vertices[last]
+	before := solution.Vertices[solution.PrevCluster[first]]
+	after := solution.Vertices[solution.NextCluster[last]]
+
+	// the edges inside the segment change their direction once it's reversed, which
+	// matters for asymmetric instances only. it's computed once for the segment, so
+	// that every position is evaluated in constant time
+
+	inner := 0
+	if c.Reversal && !inst.Symmetric {
+		for cluster := first; cluster != last; cluster = solution.NextCluster[cluster] {
+			u := solution.Vertices[cluster]
+			v := solution.Vertices[solution.NextCluster[cluster]]
+			inner += inst.GetDistance(v, u) - inst.GetDistance(u, v)
+		}
+	}
+
+	removal := inst.GetDistance(before, after) - inst.GetDistance(before, a) - inst.GetDistance(b, after)
+
+	// the segment is inserted between clusters p and q. placing it after its own
+	// preceding cluster only makes sense when it's reversed
+
+	bestDelta := 0
+	bestTarget := -1
+	bestReversed := false
+	for p := 0; p < inst.ClusterCount; p++ {
+		if inSegment[p] {
+			continue
+		}
+		q := solution.NextCluster[p]
+		pv, qv := solution.Vertices[p], solution.Vertices[q]
+
+		if p != solution.PrevCluster[first] {
+			delta := removal - inst.GetDistance(pv, qv) + inst.GetDistance(pv, a) + inst.GetDistance(b, qv)
+			if delta < bestDelta {
+				bestDelta, bestTarget, bestReversed = delta, p, false
+			}
+		}
+
+		if c.Reversal {
+			var delta int
+			if p == solution.PrevCluster[first] {
+				delta = inst.GetDistance(pv, b) + inst.GetDistance(a, after) -
+					inst.GetDistance(pv, a) - inst.GetDistance(b, after) + inner
+			} else {
+				delta = removal - inst.GetDistance(pv, qv) + inst.GetDistance(pv, b) + inst.GetDistance(a, qv) + inner
+			}
+			if delta < bestDelta {
+				bestDelta, bestTarget, bestReversed = delta, p, true
+			}
+		}
+	}
+
+	if bestTarget < 0 {
+		return 0
+	}
+	solution.MoveSegment(first, last, bestTarget, bestReversed)
+	return bestDelta
+}
+
+var orOptSpec = &ComponentSpec{
+	Name: "or-opt",
+	Parameters: []ParameterSpec{
+		{Name: "min-length", Type: IntParameter, Min: 1, Default: 2},
+		{Name: "max-length", Type: IntParameter, Min: 1, Default: 3},
+		{Name: "reversal", Type: BoolParameter, Default: true},
+	},
+	check: func(p Parameters) error {
+		if p.getInt("min-length") > p.getInt("max-length") {
+			return fmt.Errorf("parameter `min-length` is %d, expected at most `max-length` %d", p.getInt("min-length"), p.getInt("max-length"))
+		}
+		return nil
+	},
+	build: func(p Parameters) Component {
+		return NewOrOpt(p.getInt("min-length"), p.getInt("max-length"), p.getBool("reversal"))
+	},
+}
+
+func (c *OrOpt) getSpec() *ComponentSpec {
+	return orOptSpec
+}
+
+func (c *OrOpt) getParameters() Parameters {
+	return Parameters{"min-length": c.MinLength, "max-length": c.MaxLength, "reversal": c.Reversal}
+}
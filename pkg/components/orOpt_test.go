@@ -0,0 +1,68 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOrOpt_Apply(t *testing.T) {
+	inst := lineInstance(t)
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	// 0 -> 3 -> 4 -> 1 -> 2 -> 5 -> 0 = 3 + 1 + 3 + 1 + 3 + 5 = 16. moving
+	// the segment 3 -> 4 after 2 gives the optimal tour of the length of 10
+
+	setTour(solution, []int{0, 3, 4, 1, 2, 5})
+	assert.Equal(t, 16, solution.Distance)
+
+	gain := NewOrOpt(2, 2, false).apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 6, gain)
+	assert.Equal(t, 10, solution.Distance)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestOrOpt_ApplyReversed(t *testing.T) {
+
+	// single-vertex clusters on a grid of 3 x 2 with Manhattan distances
+	//
+	//   5  4  3
+	//   0  1  2
+
+	inst := &gtsp.Instance{
+		NodeCount:    6,
+		ClusterCount: 6,
+		Symmetric:    true,
+		Coordinates:  []gtsp.NodeCoord{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}, {X: 2, Y: 2}, {X: 0, Y: 2}},
+		Metric:       gtsp.Manhattan,
+	}
+	inst.SetClusters(map[int][]int{0: {0}, 1: {1}, 2: {2}, 3: {3}, 4: {4}, 5: {5}})
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	// 0 -> 1 -> 2 -> 4 -> 3 -> 5 -> 0 = 2 + 2 + 4 + 2 + 4 + 2 = 16. the segment 4 -> 3
+	// only fits between 2 and 5 once it's reversed
+
+	setTour(solution, []int{0, 1, 2, 4, 3, 5})
+	assert.Equal(t, 16, solution.Distance)
+
+	assert.Equal(t, 0, NewOrOpt(2, 2, false).apply(solution, pkg.NewRand(1)))
+
+	gain := NewOrOpt(2, 2, true).apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 4, gain)
+	assert.Equal(t, 12, solution.Distance)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestOrOpt_ApplySmallTour(t *testing.T) {
+	inst, err := gtsp.NewInstance(6, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// with 3 clusters only single clusters can be moved
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	assert.Equal(t, 0, NewOrOpt(2, 3, true).apply(solution, pkg.NewRand(1)))
+	assert.True(t, solution.IsFeasible())
+}
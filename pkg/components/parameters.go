@@ -0,0 +1,193 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+type ParameterType string
+
+const (
+	IntParameter    ParameterType = "int"
+	BoolParameter   ParameterType = "bool"
+	ChoiceParameter ParameterType = "choice" // one of the listed string values
+)
+
+// ParameterSpec declares a tunable of a component
+type ParameterSpec struct {
+	Name    string        `json:"name" yaml:"name"`
+	Type    ParameterType `json:"type" yaml:"type"`
+	Min     int           `json:"min,omitempty" yaml:"min,omitempty"`       // smallest value of an int parameter
+	Max     int           `json:"max,omitempty" yaml:"max,omitempty"`       // largest value of an int parameter, 0 for no limit
+	Values  []string      `json:"values,omitempty" yaml:"values,omitempty"` // allowed values of a choice parameter
+	Default interface{}   `json:"default" yaml:"default"`
+}
+
+// ComponentSpec declares a component and its tunables, so that it can be built from
+// a configuration file. every component is listed in `specs`
+type ComponentSpec struct {
+	Name       string          `json:"name" yaml:"name"`
+	Parameters []ParameterSpec `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+
+	// optionally checks constraints between the parameters, once each of them is valid
+	check func(parameters Parameters) error
+
+	// builds the component from parameters which have already been validated
+	build func(parameters Parameters) Component
+}
+
+// Parameters are the values of the tunables of a component, by name. values are
+// int, bool or string, depending on the type of the parameter
+type Parameters map[string]interface{}
+
+var specs = []*ComponentSpec{
+	insertionSpec,
+	twoOptSpec,
+	clusterOptimisationSpec,
+	vertexMutationSpec,
+	orOptSpec,
+	swapSpec,
+	doubleBridgeSpec,
+	randomInsertionSpec,
+	restartSpec,
+}
+
+// Specs lists the declarations of all the components
+func Specs() []*ComponentSpec {
+	return specs
+}
+
+// NewComponent builds the component of the given name. parameters which aren't
+// given take their default values
+func NewComponent(name string, parameters Parameters) (Component, error) {
+	for _, spec := range specs {
+		if spec.Name == name {
+			validated, err := spec.validate(parameters)
+			if err != nil {
+				return nil, err
+			}
+			return spec.build(validated), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown component `%s`", name)
+}
+
+// checks the parameters against the declaration, and returns them with numbers
+// converted to int and the missing parameters set to their defaults
+func (spec *ComponentSpec) validate(parameters Parameters) (Parameters, error) {
+	declared := make(map[string]bool)
+	for _, p := range spec.Parameters {
+		declared[p.Name] = true
+	}
+
+	// unknown parameters are reported in a stable order
+
+	var unknown []string
+	for name := range parameters {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("component `%s` has no parameter `%s`", spec.Name, strings.Join(unknown, "`, `"))
+	}
+
+	validated := make(Parameters)
+	for _, p := range spec.Parameters {
+		value, ok := parameters[p.Name]
+		if !ok {
+			validated[p.Name] = p.Default
+			continue
+		}
+		value, err := p.validate(value)
+		if err != nil {
+			return nil, fmt.Errorf("component `%s`: %v", spec.Name, err)
+		}
+		validated[p.Name] = value
+	}
+
+	if spec.check != nil {
+		if err := spec.check(validated); err != nil {
+			return nil, fmt.Errorf("component `%s`: %v", spec.Name, err)
+		}
+	}
+	return validated, nil
+}
+
+func (p ParameterSpec) validate(value interface{}) (interface{}, error) {
+	switch p.Type {
+	case IntParameter:
+
+		// JSON decodes every number as float64, and YAML as int
+
+		var n int
+		switch v := value.(type) {
+		case int:
+			n = v
+		case int64:
+			n = int(v)
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("parameter `%s` is %v, expected an integer", p.Name, v)
+			}
+			n = int(v)
+		default:
+			return nil, fmt.Errorf("parameter `%s` is %v, expected an integer", p.Name, value)
+		}
+		if n < p.Min {
+			return nil, fmt.Errorf("parameter `%s` is %d, expected at least %d", p.Name, n, p.Min)
+		}
+		if p.Max != 0 && n > p.Max {
+			return nil, fmt.Errorf("parameter `%s` is %d, expected at most %d", p.Name, n, p.Max)
+		}
+		return n, nil
+
+	case BoolParameter:
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("parameter `%s` is %v, expected true or false", p.Name, value)
+		}
+		return value, nil
+
+	case ChoiceParameter:
+		s, _ := value.(string)
+		for _, allowed := range p.Values {
+			if s == allowed {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("parameter `%s` is %v, expected one of %s", p.Name, value, strings.Join(p.Values, ", "))
+	}
+	return nil, fmt.Errorf("parameter `%s` has unknown type %s", p.Name, p.Type)
+}
+
+// typed access to validated parameters, which are guaranteed to be present
+
+func (parameters Parameters) getInt(name string) int {
+	return parameters[name].(int)
+}
+
+func (parameters Parameters) getBool(name string) bool {
+	return parameters[name].(bool)
+}
+
+// returns the position of the value of a choice parameter among `values`
+func (parameters Parameters) getChoice(name string, values []string) int {
+	for i, v := range values {
+		if v == parameters[name] {
+			return i
+		}
+	}
+	return -1
+}
+
+// returns the name of the i-th value of a choice parameter. values out of range are
+// written as numbers, so that validation can report them
+func choiceName(values []string, i int) string {
+	if i < 0 || i >= len(values) {
+		return fmt.Sprint(i)
+	}
+	return values[i]
+}
@@ -0,0 +1,76 @@
+package components
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewComponent_Defaults(t *testing.T) {
+	c, err := NewComponent("or-opt", nil)
+	assert.True(t, err == nil)
+	assert.Equal(t, NewOrOpt(2, 3, true), c)
+
+	c, err = NewComponent("vertex-mutation", Parameters{"mode": "best"})
+	assert.True(t, err == nil)
+	assert.Equal(t, NewVertexMutation(BestVertexMutation), c)
+
+	// JSON numbers are decoded as float64
+
+	c, err = NewComponent("double-bridge", Parameters{"strength": 3.0})
+	assert.True(t, err == nil)
+	assert.Equal(t, must(NewDoubleBridge(3)), c)
+}
+
+func TestNewComponent_Invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters Parameters
+		err        string
+	}{
+		{"three-opt", nil, "unknown component `three-opt`"},
+		{"swap", Parameters{"strength": 2, "depth": 1}, "component `swap` has no parameter `depth`, `strength`"},
+		{"restart", Parameters{"strength": 0}, "component `restart`: parameter `strength` is 0, expected at least 1"},
+		{"restart", Parameters{"strength": 1.5}, "component `restart`: parameter `strength` is 1.5, expected an integer"},
+		{"restart", Parameters{"strength": "many"}, "component `restart`: parameter `strength` is many, expected an integer"},
+		{"insertion", Parameters{"best-vertex": 1}, "component `insertion`: parameter `best-vertex` is 1, expected true or false"},
+		{"swap", Parameters{"mode": "worst"}, "component `swap`: parameter `mode` is worst, expected one of first, best"},
+		{"or-opt", Parameters{"min-length": 4, "max-length": 2}, "component `or-opt`: parameter `min-length` is 4, expected at most `max-length` 2"},
+		{"or-opt", Parameters{"min-length": 4}, "component `or-opt`: parameter `min-length` is 4, expected at most `max-length` 3"},
+	}
+
+	for _, test := range tests {
+		_, err := NewComponent(test.name, test.parameters)
+		assert.Equal(t, test.err, err.Error())
+	}
+}
+
+// every component is rebuilt from its own parameters, and its defaults are valid
+func TestSpecs_RoundTrip(t *testing.T) {
+	for _, spec := range Specs() {
+		c, err := NewComponent(spec.Name, nil)
+		assert.True(t, err == nil, spec.Name)
+		assert.Equal(t, spec, c.getSpec())
+
+		rebuilt, err := NewComponent(spec.Name, c.getParameters())
+		assert.True(t, err == nil, spec.Name)
+		assert.Equal(t, c, rebuilt)
+	}
+}
+
+func TestNewCMCS_InvalidParameters(t *testing.T) {
+	config := Configuration{
+		Components: []Component{NewOrOpt(0, 3, true)},
+		Success:    [][]float64{{1}},
+		Failure:    [][]float64{{1}},
+	}
+	_, err := NewCMCS(config, 0, 10)
+	assert.Equal(t, "component `or-opt`: parameter `min-length` is 0, expected at least 1", err.Error())
+
+	config.Components = []Component{&VertexMutation{Mode: RandomVertexMutation}}
+	_, err = NewCMCS(config, 0, 10)
+	assert.Equal(t, "component `vertex-mutation`: parameter `strength` is 0, expected at least 1", err.Error())
+
+	config.Components = []Component{NewSwap(SwapMode(5))}
+	_, err = NewCMCS(config, 0, 10)
+	assert.Equal(t, "component `swap`: parameter `mode` is 5, expected one of first, best", err.Error())
+}
@@ -0,0 +1,70 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// RandomInsertion is a perturbation which moves distinct random clusters to random
+// positions in the tour, regardless of the change of the distance
+type RandomInsertion struct {
+	// number of clusters moved, at least 1
+	Strength int
+}
+
+func NewRandomInsertion(strength int) (*RandomInsertion, error) {
+	if err := checkStrength(strength); err != nil {
+		return nil, err
+	}
+	return &RandomInsertion{Strength: strength}, nil
+}
+
+func (c *RandomInsertion) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	n := solution.Instance.ClusterCount
+
+	// with fewer than 3 clusters every cluster order gives the same tour
+
+	if n < 3 {
+		return 0
+	}
+
+	before := solution.Distance
+	for _, cluster := range randomClusters(n, c.Strength, rnd) {
+
+		// the cluster is never inserted after itself or its preceding cluster,
+		// so every insertion actually changes the order
+
+		low, high := cluster, solution.PrevCluster[cluster]
+		if low > high {
+			low, high = high, low
+		}
+		target := rnd.GetRandomInteger(n - 2)
+		if target >= low {
+			target++
+		}
+		if target >= high {
+			target++
+		}
+		solution.InsertCluster(cluster, target)
+	}
+	return before - solution.Distance
+}
+
+var randomInsertionSpec = &ComponentSpec{
+	Name: "random-insertion",
+	Parameters: []ParameterSpec{
+		{Name: "strength", Type: IntParameter, Min: 1, Default: 1},
+	},
+	build: func(p Parameters) Component {
+		c, _ := NewRandomInsertion(p.getInt("strength")) // the strength is already validated
+		return c
+	},
+}
+
+func (c *RandomInsertion) getSpec() *ComponentSpec {
+	return randomInsertionSpec
+}
+
+func (c *RandomInsertion) getParameters() Parameters {
+	return Parameters{"strength": c.Strength}
+}
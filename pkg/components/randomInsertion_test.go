@@ -0,0 +1,41 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRandomInsertion_ApplyChangesOrder(t *testing.T) {
+	inst := lineInstance(t)
+	insertion, err := NewRandomInsertion(1)
+	assert.True(t, err == nil)
+
+	// a single insertion never puts the cluster back where it was
+
+	for seed := int64(1); seed <= 20; seed++ {
+		solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+		setTour(solution, []int{0, 1, 2, 3, 4, 5})
+		next := append([]int(nil), solution.NextCluster...)
+
+		insertion.apply(solution, pkg.NewRand(seed))
+
+		assert.NotEqual(t, next, solution.NextCluster, "seed %d", seed)
+		assert.True(t, solution.IsFeasible())
+	}
+}
+
+func TestRandomInsertion_ApplyStrongerThanClusters(t *testing.T) {
+	inst := lineInstance(t)
+	insertion, err := NewRandomInsertion(100)
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	before := solution.Distance
+
+	gain := insertion.apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, before-solution.Distance, gain)
+	assert.True(t, solution.IsFeasible())
+}
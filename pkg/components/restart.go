@@ -0,0 +1,63 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// Restart is a perturbation which replaces the solution with a new random one. with a
+// strength above 1, the best of that many random solutions is taken
+type Restart struct {
+	// number of random solutions generated, at least 1
+	Strength int
+}
+
+func NewRestart(strength int) (*Restart, error) {
+	if err := checkStrength(strength); err != nil {
+		return nil, err
+	}
+	return &Restart{Strength: strength}, nil
+}
+
+func (c *Restart) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	var best *gtsp.Solution
+	for i := 0; i < c.Strength; i++ {
+		if candidate := gtsp.GenerateSolution(solution.Instance, rnd); best == nil || candidate.Distance < best.Distance {
+			best = candidate
+		}
+	}
+
+	// a restart built without its constructor may generate no solution at all
+
+	if best == nil {
+		return 0
+	}
+
+	// the solution is updated in place, the caller keeps a pointer to it
+
+	gain := solution.Distance - best.Distance
+	copy(solution.Vertices, best.Vertices)
+	copy(solution.PrevCluster, best.PrevCluster)
+	copy(solution.NextCluster, best.NextCluster)
+	solution.Distance = best.Distance
+	return gain
+}
+
+var restartSpec = &ComponentSpec{
+	Name: "restart",
+	Parameters: []ParameterSpec{
+		{Name: "strength", Type: IntParameter, Min: 1, Default: 1},
+	},
+	build: func(p Parameters) Component {
+		c, _ := NewRestart(p.getInt("strength")) // the strength is already validated
+		return c
+	},
+}
+
+func (c *Restart) getSpec() *ComponentSpec {
+	return restartSpec
+}
+
+func (c *Restart) getParameters() Parameters {
+	return Parameters{"strength": c.Strength}
+}
@@ -0,0 +1,47 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRestart_ApplyTakesBest(t *testing.T) {
+	inst, err := gtsp.NewInstance(40, 10, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// the same random solutions are generated again, the shortest one is expected
+
+	rnd := pkg.NewRand(2)
+	best := gtsp.GenerateSolution(inst, rnd)
+	for i := 1; i < 20; i++ {
+		if candidate := gtsp.GenerateSolution(inst, rnd); candidate.Distance < best.Distance {
+			best = candidate
+		}
+	}
+
+	restart, err := NewRestart(20)
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	before := solution.Distance
+	gain := restart.apply(solution, pkg.NewRand(2))
+
+	assert.Equal(t, best.Distance, solution.Distance)
+	assert.Equal(t, best.Tour(), solution.Tour())
+	assert.Equal(t, before-best.Distance, gain)
+}
+
+func TestRestart_ApplyNoStrength(t *testing.T) {
+	inst, err := gtsp.NewInstance(40, 10, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// a restart which generates no solution keeps the current one
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	tour := solution.Tour()
+
+	assert.Equal(t, 0, (&Restart{}).apply(solution, pkg.NewRand(2)))
+	assert.Equal(t, tour, solution.Tour())
+}
@@ -0,0 +1,82 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+type SwapMode int
+
+const (
+	// applies the first swap found to improve the solution
+	FirstImprovementSwap SwapMode = iota
+	// applies the swap improving the solution the most, after trying every pair of clusters
+	BestImprovementSwap
+)
+
+// names of the modes in configuration files, in the order of their values
+var swapModes = []string{"first", "best"}
+
+// Swap is a hill climber which exchanges the positions of two clusters in the tour,
+// until no such exchange improves the solution
+type Swap struct {
+	Mode SwapMode
+}
+
+func NewSwap(mode SwapMode) *Swap {
+	return &Swap{Mode: mode}
+}
+
+func (c *Swap) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	n := solution.Instance.ClusterCount
+
+	// with fewer than 3 clusters every cluster order gives the same tour
+
+	if n < 3 {
+		return 0
+	}
+
+	gain := 0
+	for {
+		bestDelta := 0
+		bestA, bestB := -1, -1
+
+	search:
+		for a := 0; a < n; a++ {
+			for b := a + 1; b < n; b++ {
+				if delta := solution.SwapClustersDelta(a, b); delta < bestDelta {
+					bestDelta = delta
+					bestA, bestB = a, b
+					if c.Mode == FirstImprovementSwap {
+						break search
+					}
+				}
+			}
+		}
+
+		if bestA < 0 {
+			return gain
+		}
+
+		solution.SwapClusters(bestA, bestB)
+		gain -= bestDelta
+	}
+}
+
+var swapSpec = &ComponentSpec{
+	Name: "swap",
+	Parameters: []ParameterSpec{
+		{Name: "mode", Type: ChoiceParameter, Values: swapModes, Default: "first"},
+	},
+	build: func(p Parameters) Component {
+		return NewSwap(SwapMode(p.getChoice("mode", swapModes)))
+	},
+}
+
+func (c *Swap) getSpec() *ComponentSpec {
+	return swapSpec
+}
+
+func (c *Swap) getParameters() Parameters {
+	return Parameters{"mode": choiceName(swapModes, int(c.Mode))}
+}
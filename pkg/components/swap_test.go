@@ -0,0 +1,44 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSwap_Apply(t *testing.T) {
+	for _, mode := range []SwapMode{FirstImprovementSwap, BestImprovementSwap} {
+		inst := lineInstance(t)
+		solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+		// 0 -> 4 -> 2 -> 3 -> 1 -> 5 -> 0 = 4 + 2 + 1 + 2 + 4 + 5 = 18. swapping
+		// clusters 1 and 4 gives the optimal tour of the length of 10
+
+		setTour(solution, []int{0, 4, 2, 3, 1, 5})
+		assert.Equal(t, 18, solution.Distance)
+
+		gain := NewSwap(mode).apply(solution, pkg.NewRand(1))
+
+		assert.Equal(t, 8, gain)
+		assert.Equal(t, 10, solution.Distance)
+		assert.True(t, solution.IsFeasible())
+	}
+}
+
+func TestSwap_ApplyAdjacent(t *testing.T) {
+	inst := lineInstance(t)
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	// 0 -> 1 -> 3 -> 2 -> 4 -> 5 -> 0 = 1 + 2 + 1 + 2 + 1 + 5 = 12, only the
+	// adjacent clusters 2 and 3 are out of place
+
+	setTour(solution, []int{0, 1, 3, 2, 4, 5})
+	assert.Equal(t, 12, solution.Distance)
+
+	gain := NewSwap(BestImprovementSwap).apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 2, gain)
+	assert.Equal(t, 10, solution.Distance)
+	assert.True(t, solution.IsFeasible())
+}
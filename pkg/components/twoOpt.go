@@ -0,0 +1,99 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// TwoOpt is a hill climber which replaces two edges of the tour with two other edges,
+// reversing the segment between them, until no such exchange improves the solution
+type TwoOpt struct{}
+
+func NewTwoOpt() *TwoOpt {
+	return &TwoOpt{}
+}
+
+func (c *TwoOpt) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	n := solution.Instance.ClusterCount
+	if n < 4 {
+		return 0
+	}
+
+	gain := 0
+	tour := make([]int, n)
+	forward := make([]int, n)
+	backward := make([]int, n)
+
+	for {
+
+		// lay the tour out in an array, so that segments can be addressed by
+		// positions. for asymmetric instances also keep prefix sums of the tour
+		// in both directions, so the cost of a reversed segment is known in O(1)
+
+		tour[0] = 0
+		for i := 1; i < n; i++ {
+			tour[i] = solution.NextCluster[tour[i-1]]
+		}
+		if !solution.Instance.Symmetric {
+			for i := 1; i < n; i++ {
+				from := solution.Vertices[tour[i-1]]
+				to := solution.Vertices[tour[i]]
+				forward[i] = forward[i-1] + solution.Instance.GetDistance(from, to)
+				backward[i] = backward[i-1] + solution.Instance.GetDistance(to, from)
+			}
+		}
+
+		// edges (tour[i], tour[i+1]) and (tour[j], tour[j+1]) are replaced with
+		// (tour[i], tour[j]) and (tour[i+1], tour[j+1])
+
+		bestDelta := 0
+		bestI, bestJ := -1, -1
+		for i := 0; i < n-2; i++ {
+			a := solution.Vertices[tour[i]]
+			a1 := solution.Vertices[tour[i+1]]
+			for j := i + 2; j < n; j++ {
+
+				// the edges are adjacent when the last edge closes the tour
+
+				if i == 0 && j == n-1 {
+					continue
+				}
+				b := solution.Vertices[tour[j]]
+				b1 := solution.Vertices[tour[(j+1)%n]]
+
+				delta := solution.Instance.GetDistance(a, b) + solution.Instance.GetDistance(a1, b1) -
+					solution.Instance.GetDistance(a, a1) - solution.Instance.GetDistance(b, b1)
+				if !solution.Instance.Symmetric {
+					delta += backward[j] - backward[i+1] - forward[j] + forward[i+1]
+				}
+
+				if delta < bestDelta {
+					bestDelta = delta
+					bestI, bestJ = i, j
+				}
+			}
+		}
+
+		if bestI < 0 {
+			return gain
+		}
+
+		solution.ReverseSegment(tour[bestI+1], tour[bestJ])
+		gain -= bestDelta
+	}
+}
+
+var twoOptSpec = &ComponentSpec{
+	Name: "two-opt",
+	build: func(p Parameters) Component {
+		return NewTwoOpt()
+	},
+}
+
+func (c *TwoOpt) getSpec() *ComponentSpec {
+	return twoOptSpec
+}
+
+func (c *TwoOpt) getParameters() Parameters {
+	return Parameters{}
+}
@@ -0,0 +1,68 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTwoOpt_Apply(t *testing.T) {
+	inst, err := gtsp.NewInstance(5, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1},
+		2: {2},
+		3: {3},
+		4: {4},
+	})
+
+	// points on a line, the optimal tour visits them in order and has the length of 8
+
+	inst.Distances = [][]int{
+		{0, 1, 2, 3, 4},
+		{1, 0, 1, 2, 3},
+		{2, 1, 0, 1, 2},
+		{3, 2, 1, 0, 1},
+		{4, 3, 2, 1, 0},
+	}
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	// 0 -> 3 -> 2 -> 1 -> 4 -> 0 = 3 + 1 + 1 + 3 + 4 = 12
+
+	solution.PrevCluster = []int{4, 2, 3, 0, 1}
+	solution.NextCluster = []int{3, 4, 1, 2, 0}
+	solution.CalculateDistance()
+	assert.Equal(t, 12, solution.Distance)
+
+	gain := NewTwoOpt().apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 4, gain)
+	assert.Equal(t, 8, solution.Distance)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestTwoOpt_ApplyIsConsistent(t *testing.T) {
+	for _, asymmetry := range []int{0, 50} {
+		inst, err := gtsp.NewInstance(60, 15, pkg.NewRand(1), gtsp.Asymmetric(asymmetry))
+		assert.True(t, err == nil)
+
+		solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+		before := solution.Distance
+
+		twoOpt := NewTwoOpt()
+		gain := twoOpt.apply(solution, pkg.NewRand(1))
+		after := solution.Distance
+
+		assert.Equal(t, before-after, gain)
+		assert.True(t, solution.IsFeasible())
+
+		solution.CalculateDistance()
+		assert.Equal(t, after, solution.Distance)
+
+		assert.Equal(t, 0, twoOpt.apply(solution, pkg.NewRand(1)))
+	}
+}
@@ -1 +1,105 @@
 package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+type VertexMutationMode int
+
+const (
+	// replaces the vertices of random clusters with other random vertices
+	RandomVertexMutation VertexMutationMode = iota
+	// replaces the vertex of every cluster with the one minimising its two incident edges
+	BestVertexMutation
+)
+
+// names of the modes in configuration files, in the order of their values
+var vertexMutationModes = []string{"random", "best"}
+
+// VertexMutation changes the vertices visited in clusters, keeping the order of clusters fixed
+type VertexMutation struct {
+	Mode VertexMutationMode
+	// number of distinct clusters changed by RandomVertexMutation, at least 1
+	Strength int
+}
+
+func NewVertexMutation(mode VertexMutationMode) *VertexMutation {
+	return &VertexMutation{Mode: mode, Strength: 1}
+}
+
+// NewRandomVertexMutation changes the vertices of `strength` distinct random clusters
+func NewRandomVertexMutation(strength int) (*VertexMutation, error) {
+	if err := checkStrength(strength); err != nil {
+		return nil, err
+	}
+	return &VertexMutation{Mode: RandomVertexMutation, Strength: strength}, nil
+}
+
+func (c *VertexMutation) apply(solution *gtsp.Solution, rnd *pkg.Rand) int {
+	if c.Mode == RandomVertexMutation {
+		gain := 0
+		for _, cluster := range randomClusters(solution.Instance.ClusterCount, c.Strength, rnd) {
+			gain += solution.SwapVertexInCluster(cluster, rnd)
+		}
+		return gain
+	}
+
+	// go through the clusters in the tour order, so every change is taken
+	// into account when the vertex of the next cluster is selected
+
+	gain := 0
+	cluster := solution.NextCluster[0]
+	for i := 0; i < solution.Instance.ClusterCount; i++ {
+		gain += bestVertex(solution, cluster)
+		cluster = solution.NextCluster[cluster]
+	}
+	return gain
+}
+
+var vertexMutationSpec = &ComponentSpec{
+	Name: "vertex-mutation",
+	Parameters: []ParameterSpec{
+		{Name: "mode", Type: ChoiceParameter, Values: vertexMutationModes, Default: "random"},
+		{Name: "strength", Type: IntParameter, Min: 1, Default: 1},
+	},
+	build: func(p Parameters) Component {
+		mode := VertexMutationMode(p.getChoice("mode", vertexMutationModes))
+		return &VertexMutation{Mode: mode, Strength: p.getInt("strength")}
+	},
+}
+
+func (c *VertexMutation) getSpec() *ComponentSpec {
+	return vertexMutationSpec
+}
+
+func (c *VertexMutation) getParameters() Parameters {
+	return Parameters{"mode": choiceName(vertexMutationModes, int(c.Mode)), "strength": c.Strength}
+}
+
+// replaces the vertex of the cluster with the one minimising the length of its incident
+// edges, given the vertices of neighbouring clusters. returns the gain
+func bestVertex(solution *gtsp.Solution, cluster int) int {
+	inst := solution.Instance
+	if inst.ClusterCount == 1 {
+		return 0
+	}
+
+	prevVertex := solution.Vertices[solution.PrevCluster[cluster]]
+	nextVertex := solution.Vertices[solution.NextCluster[cluster]]
+
+	best := solution.Vertices[cluster]
+	bestCost := inst.GetDistance(prevVertex, best) + inst.GetDistance(best, nextVertex)
+	for _, v := range inst.Cluster(cluster) {
+		cost := inst.GetDistance(prevVertex, v) + inst.GetDistance(v, nextVertex)
+		if cost < bestCost {
+			best = v
+			bestCost = cost
+		}
+	}
+
+	if best == solution.Vertices[cluster] {
+		return 0
+	}
+	return solution.SetVertex(cluster, best)
+}
@@ -0,0 +1,105 @@
+package components
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVertexMutation_ApplyBest(t *testing.T) {
+	inst, err := gtsp.NewInstance(5, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1, 2, 3},
+		2: {4},
+	})
+
+	inst.Distances = [][]int{
+		{0, 10, 6, 2, 5},
+		{10, 0, 0, 0, 10},
+		{6, 0, 0, 0, 3},
+		{2, 0, 0, 0, 7},
+		{5, 10, 3, 7, 0},
+	}
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	solution.Vertices = []int{0, 1, 4}
+	solution.CalculateDistance()
+
+	// 0-1 : 10, 1-4 : 10, 4-0 : 5
+
+	assert.Equal(t, 25, solution.Distance)
+
+	// vertex 2 costs 6 + 3 = 9, vertex 3 costs 2 + 7 = 9, so the first one is kept
+
+	gain := NewVertexMutation(BestVertexMutation).apply(solution, pkg.NewRand(1))
+
+	assert.Equal(t, 11, gain)
+	assert.Equal(t, 14, solution.Distance)
+	assert.Equal(t, 2, solution.Vertices[1])
+}
+
+func TestVertexMutation_ApplyBestIsConsistent(t *testing.T) {
+	inst, err := gtsp.NewInstance(30, 6, pkg.NewRand(1), gtsp.Asymmetric(50))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	before := solution.Distance
+
+	gain := NewVertexMutation(BestVertexMutation).apply(solution, pkg.NewRand(1))
+	after := solution.Distance
+
+	assert.True(t, gain >= 0)
+	assert.Equal(t, before-after, gain)
+	assert.True(t, solution.IsFeasible())
+
+	solution.CalculateDistance()
+	assert.Equal(t, after, solution.Distance)
+}
+
+func TestVertexMutation_ApplyRandom(t *testing.T) {
+	inst, err := gtsp.NewInstance(30, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	mutation := NewVertexMutation(RandomVertexMutation)
+
+	for i := 0; i < 20; i++ {
+		before := solution.Distance
+		gain := mutation.apply(solution, pkg.NewRand(1))
+		after := solution.Distance
+
+		assert.Equal(t, before-after, gain)
+
+		solution.CalculateDistance()
+		assert.Equal(t, after, solution.Distance)
+	}
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestVertexMutation_ApplyRandomStrength(t *testing.T) {
+	inst, err := gtsp.NewInstance(30, 6, pkg.NewRand(1), gtsp.WithClusters(gtsp.EqualClusters))
+	assert.True(t, err == nil)
+
+	// every cluster has more than one vertex, so each of the chosen clusters changes
+
+	mutation, err := NewRandomVertexMutation(3)
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+	vertices := append([]int(nil), solution.Vertices...)
+
+	mutation.apply(solution, pkg.NewRand(1))
+
+	changed := 0
+	for cluster, v := range vertices {
+		if solution.Vertices[cluster] != v {
+			changed++
+		}
+	}
+	assert.Equal(t, 3, changed)
+	assert.True(t, solution.IsFeasible())
+}
@@ -0,0 +1,145 @@
+package exact
+
+import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"math"
+)
+
+// MaxClusters is the largest number of clusters Solve accepts. time grows as
+// O(2^M * N^2) and memory as O(2^M * N), which is still fine at this size
+const MaxClusters = 16
+
+const infinity = math.MaxInt64 / 4
+
+// Solve finds an optimal solution with the Held–Karp dynamic programming over subsets
+// of clusters. the tour is anchored at the smallest cluster, the first one on ties, and
+// the programme is run once for each of its vertices
+func Solve(instance *gtsp.Instance) (*gtsp.Solution, error) {
+	if instance.ClusterCount > MaxClusters {
+		return nil, fmt.Errorf("instance has %d clusters, at most %d can be solved exactly", instance.ClusterCount, MaxClusters)
+	}
+
+	first := instance.GetMinCluster()
+
+	// a single cluster is visited by a loop of a single vertex
+
+	if instance.ClusterCount == 1 {
+		best := instance.Cluster(first)[0]
+		for _, v := range instance.Cluster(first) {
+			if instance.GetDistance(v, v) < instance.GetDistance(best, best) {
+				best = v
+			}
+		}
+		return gtsp.SolutionFromTour(instance, []int{best})
+	}
+
+	// the other clusters are numbered 0..M-2, which is their bit in the subset mask,
+	// and their vertices are numbered consecutively, so the table is a plain slice
+
+	var clusters [][]int
+	for c := 0; c < instance.ClusterCount; c++ {
+		if c != first {
+			clusters = append(clusters, instance.Cluster(c))
+		}
+	}
+	var vertices, bit []int
+	for b, cluster := range clusters {
+		for _, v := range cluster {
+			vertices = append(vertices, v)
+			bit = append(bit, b)
+		}
+	}
+
+	h := &heldKarp{
+		instance: instance,
+		clusters: clusters,
+		vertices: vertices,
+		bit:      bit,
+		cost:     make([]int, (1<<uint(len(clusters)))*len(vertices)),
+		pred:     make([]int32, (1<<uint(len(clusters)))*len(vertices)),
+	}
+
+	bestDistance := infinity
+	var bestTour []int
+	for _, start := range instance.Cluster(first) {
+		if distance, tour := h.solve(start); distance < bestDistance {
+			bestDistance = distance
+			bestTour = tour
+		}
+	}
+
+	return gtsp.SolutionFromTour(instance, bestTour)
+}
+
+type heldKarp struct {
+	instance *gtsp.Instance
+	clusters [][]int
+	vertices []int // vertices of every cluster but the first one
+	bit      []int // cluster bit of each of the vertices
+
+	// cost[mask*len(vertices)+i] is the length of the shortest path from the start vertex
+	// through every cluster of the mask, ending at vertices[i], and pred is the index of
+	// the vertex preceding vertices[i] on that path, -1 for the start vertex
+	cost []int
+	pred []int32
+}
+
+// returns the length of the shortest tour through the start vertex, and the tour itself
+func (h *heldKarp) solve(start int) (int, []int) {
+	n := len(h.vertices)
+	full := 1<<uint(len(h.clusters)) - 1
+
+	for i := range h.cost {
+		h.cost[i] = infinity
+	}
+	for i, v := range h.vertices {
+		h.cost[(1<<uint(h.bit[i]))*n+i] = h.instance.GetDistance(start, v)
+		h.pred[(1<<uint(h.bit[i]))*n+i] = -1
+	}
+
+	// subsets are processed in increasing order, so every subset is final
+	// before it's extended by another cluster
+
+	for mask := 1; mask <= full; mask++ {
+		for i, u := range h.vertices {
+			if mask&(1<<uint(h.bit[i])) == 0 {
+				continue
+			}
+			from := h.cost[mask*n+i]
+			if from == infinity {
+				continue
+			}
+			for j, v := range h.vertices {
+				if mask&(1<<uint(h.bit[j])) != 0 {
+					continue
+				}
+				next := (mask|1<<uint(h.bit[j]))*n + j
+				if d := from + h.instance.GetDistance(u, v); d < h.cost[next] {
+					h.cost[next] = d
+					h.pred[next] = int32(i)
+				}
+			}
+		}
+	}
+
+	// close the cycle by returning to the start vertex
+
+	best, bestDistance := -1, infinity
+	for i, v := range h.vertices {
+		if d := h.cost[full*n+i] + h.instance.GetDistance(v, start); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+
+	tour := make([]int, len(h.clusters)+1)
+	tour[0] = start
+	mask := full
+	for k := len(h.clusters); k > 0; k-- {
+		tour[k] = h.vertices[best]
+		prev := int(h.pred[mask*n+best])
+		mask &^= 1 << uint(h.bit[best])
+		best = prev
+	}
+	return bestDistance, tour
+}
@@ -0,0 +1,156 @@
+package exact
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	inst, err := gtsp.NewInstance(6, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0, 1},
+		1: {2, 3},
+		2: {4, 5},
+	})
+
+	// the only short cycle is 1 -> 3 -> 5 -> 1
+
+	inst.Distances = [][]int{
+		{0, 9, 9, 9, 9, 9},
+		{9, 0, 9, 1, 9, 2},
+		{9, 9, 0, 9, 9, 9},
+		{9, 1, 9, 0, 1, 3},
+		{9, 9, 9, 1, 0, 9},
+		{9, 2, 9, 3, 9, 0},
+	}
+
+	solution, err := Solve(inst)
+	assert.True(t, err == nil)
+
+	assert.Equal(t, 6, solution.Distance)
+	assert.Equal(t, []int{1, 3, 5}, solution.Vertices)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestSolve_Ties(t *testing.T) {
+	inst, err := gtsp.NewInstance(6, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0, 1},
+		1: {2, 3},
+		2: {4, 5},
+	})
+
+	// the clusters are of the same size, and both 0 -> 3 -> 4 -> 0 and 1 -> 2 -> 5 -> 1
+	// are optimal. the tour is anchored at the first cluster, so the former is returned
+	// every time
+
+	inst.Distances = [][]int{
+		{0, 9, 9, 1, 1, 9},
+		{9, 0, 1, 9, 9, 1},
+		{9, 1, 0, 9, 9, 1},
+		{1, 9, 9, 0, 1, 9},
+		{1, 9, 9, 1, 0, 9},
+		{9, 1, 1, 9, 9, 0},
+	}
+
+	for i := 0; i < 100; i++ {
+		solution, err := Solve(inst)
+		assert.True(t, err == nil)
+
+		assert.Equal(t, 3, solution.Distance)
+		assert.Equal(t, []int{0, 3, 4}, solution.Vertices)
+	}
+}
+
+func TestSolve_SingleCluster(t *testing.T) {
+	inst, err := gtsp.NewInstance(4, 1, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution, err := Solve(inst)
+	assert.True(t, err == nil)
+	assert.Equal(t, 0, solution.Distance)
+}
+
+func TestSolve_TooManyClusters(t *testing.T) {
+	inst, err := gtsp.NewInstance(40, MaxClusters+1, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	_, err = Solve(inst)
+	assert.Equal(t, "instance has 17 clusters, at most 16 can be solved exactly", err.Error())
+}
+
+func TestSolve_MatchesBruteForce(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		var options []gtsp.Option
+		if seed%2 == 0 {
+			options = append(options, gtsp.Asymmetric(50))
+		}
+		inst, err := gtsp.NewInstance(14, 6, pkg.NewRand(seed), options...)
+		assert.True(t, err == nil)
+
+		solution, err := Solve(inst)
+		assert.True(t, err == nil)
+
+		// the distance has to be consistent with the tour
+
+		distance := solution.Distance
+		solution.CalculateDistance()
+		assert.Equal(t, distance, solution.Distance)
+
+		assert.Equal(t, bruteForce(inst), solution.Distance, "seed %d", seed)
+	}
+}
+
+func TestSolve_LargestInstance(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	inst, err := gtsp.NewInstance(3*MaxClusters, MaxClusters, pkg.NewRand(1), gtsp.WithClusters(gtsp.EqualClusters))
+	assert.True(t, err == nil)
+
+	solution, err := Solve(inst)
+	assert.True(t, err == nil)
+	assert.True(t, solution.IsFeasible())
+}
+
+// returns the length of the shortest tour by trying every order of clusters
+// and every choice of vertices, with cluster 0 fixed as the first one
+func bruteForce(inst *gtsp.Instance) int {
+	best := int(^uint(0) >> 1)
+	tour := make([]int, 0, inst.ClusterCount)
+	used := make([]bool, inst.ClusterCount)
+
+	var extend func(length int)
+	extend = func(length int) {
+		if len(tour) == inst.ClusterCount {
+			if total := length + inst.GetDistance(tour[len(tour)-1], tour[0]); total < best {
+				best = total
+			}
+			return
+		}
+		for c := 0; c < inst.ClusterCount; c++ {
+			if used[c] || (len(tour) == 0 && c != 0) {
+				continue
+			}
+			used[c] = true
+			for _, v := range inst.Cluster(c) {
+				step := 0
+				if len(tour) > 0 {
+					step = inst.GetDistance(tour[len(tour)-1], v)
+				}
+				tour = append(tour, v)
+				extend(length + step)
+				tour = tour[:len(tour)-1]
+			}
+			used[c] = false
+		}
+	}
+	extend(0)
+	return best
+}
@@ -0,0 +1,222 @@
+package gtsp
+
+import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"math"
+)
+
+// ClusterDistribution defines how the nodes are distributed between clusters
+type ClusterDistribution int
+
+const (
+	// every cluster gets a node, the remaining nodes go to random clusters
+	UniformClusters ClusterDistribution = iota
+	// cluster sizes differ by at most one node
+	EqualClusters
+	// cluster sizes follow a power law, a few clusters are large and most are small
+	PowerLawClusters
+	// nodes go to random clusters, as with UniformClusters, and are placed around the centre of their cluster
+	GeographicClusters
+)
+
+var clusterDistributionNames = map[ClusterDistribution]string{
+	UniformClusters:    "uniform",
+	EqualClusters:      "equal",
+	PowerLawClusters:   "power-law",
+	GeographicClusters: "geographic",
+}
+
+func (d ClusterDistribution) String() string {
+	return clusterDistributionNames[d]
+}
+
+// ClusterDistributions lists every cluster distribution
+func ClusterDistributions() []ClusterDistribution {
+	distributions := make([]ClusterDistribution, len(clusterDistributionNames))
+	for i := range distributions {
+		distributions[i] = ClusterDistribution(i)
+	}
+	return distributions
+}
+
+func ParseClusterDistribution(name string) (ClusterDistribution, error) {
+	for d, n := range clusterDistributionNames {
+		if n == name {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown cluster distribution %q, expected uniform, equal, power-law or geographic", name)
+}
+
+// Metric defines how the distance between two nodes is calculated from their coordinates
+type Metric int
+
+const (
+	Manhattan Metric = iota
+	Euclidean
+)
+
+var metricNames = map[Metric]string{
+	Manhattan: "manhattan",
+	Euclidean: "euclidean",
+}
+
+func (m Metric) String() string {
+	return metricNames[m]
+}
+
+// Metrics lists every metric
+func Metrics() []Metric {
+	metrics := make([]Metric, len(metricNames))
+	for i := range metrics {
+		metrics[i] = Metric(i)
+	}
+	return metrics
+}
+
+func ParseMetric(name string) (Metric, error) {
+	for m, n := range metricNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown metric %q, expected manhattan or euclidean", name)
+}
+
+func (m Metric) distanceFunc() func(c1, c2 NodeCoord) int {
+	if m == Euclidean {
+		return calculateEuclideanDistance
+	}
+	return calculateDistance
+}
+
+// Option changes the way NewInstance generates the instance
+type Option func(*generatorConfig)
+
+type generatorConfig struct {
+	asymmetry int
+	clusters  ClusterDistribution
+	metric    Metric
+	closure   bool
+}
+
+// Asymmetric makes NewInstance generate an asymmetric instance. the distance in each
+// direction is increased independently by up to `percent` percent of the symmetric one
+func Asymmetric(percent int) Option {
+	return func(config *generatorConfig) {
+		config.asymmetry = percent
+	}
+}
+
+// WithClusters selects the distribution of nodes between clusters, uniform by default
+func WithClusters(distribution ClusterDistribution) Option {
+	return func(config *generatorConfig) {
+		config.clusters = distribution
+	}
+}
+
+// WithMetric selects the metric used to calculate distances, Manhattan by default
+func WithMetric(metric Metric) Option {
+	return func(config *generatorConfig) {
+		config.metric = metric
+	}
+}
+
+// WithMetricClosure replaces the generated distances with shortest paths,
+// so that the instance satisfies the triangle inequality
+func WithMetricClosure() Option {
+	return func(config *generatorConfig) {
+		config.closure = true
+	}
+}
+
+func (inst *Instance) generateClusters(distribution ClusterDistribution, rnd *pkg.Rand) {
+
+	// add a single node to each cluster to ensure
+	// there's at least one node
+
+	for i := 0; i < inst.ClusterCount; i++ {
+		inst.clusters[i] = []int{i}
+	}
+
+	switch distribution {
+	case EqualClusters:
+
+		// deal the remaining nodes out in turns
+
+		for i := inst.ClusterCount; i < inst.NodeCount; i++ {
+			cluster := i % inst.ClusterCount
+			inst.clusters[cluster] = append(inst.clusters[cluster], i)
+		}
+	case PowerLawClusters:
+
+		// cluster k receives a node with the probability proportional to 1 / (k + 1)
+
+		weights := make([]float64, inst.ClusterCount)
+		total := 0.0
+		for k := range weights {
+			weights[k] = 1 / float64(k+1)
+			total += weights[k]
+		}
+		for i := inst.ClusterCount; i < inst.NodeCount; i++ {
+			cluster := pickWeighted(weights, total, rnd)
+			inst.clusters[cluster] = append(inst.clusters[cluster], i)
+		}
+	default:
+
+		// distribute remaining nodes randomly between Clusters
+
+		for i := inst.ClusterCount; i < inst.NodeCount; i++ {
+			cluster := rnd.GetRandomInteger(inst.ClusterCount)
+			inst.clusters[cluster] = append(inst.clusters[cluster], i)
+		}
+	}
+}
+
+func (inst *Instance) generateGeographicCoordinates(rnd *pkg.Rand) []NodeCoord {
+
+	// every cluster gets a random centre on the plane. its nodes are scattered
+	// around the centre, within a square small enough for clusters to be apart
+
+	size := inst.planeSize()
+	spread := int(float64(size) / (4 * math.Ceil(math.Sqrt(float64(inst.ClusterCount)))))
+	if spread < 1 {
+		spread = 1
+	}
+
+	nodes := make([]NodeCoord, inst.NodeCount)
+	for cluster := 0; cluster < inst.ClusterCount; cluster++ {
+		x := rnd.GetRandomInteger(size)
+		y := rnd.GetRandomInteger(size)
+		for _, node := range inst.clusters[cluster] {
+			nodes[node] = NodeCoord{
+				X: float64(clamp(x+rnd.GetRandomIntegerInRange(-spread, spread+1), 0, size-1)),
+				Y: float64(clamp(y+rnd.GetRandomIntegerInRange(-spread, spread+1), 0, size-1)),
+			}
+		}
+	}
+	return nodes
+}
+
+// picks an index with the probability proportional to its weight
+func pickWeighted(weights []float64, total float64, rnd *pkg.Rand) int {
+	r := rnd.GetRandomFloat() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
+
+func clamp(v, lower, upper int) int {
+	if v < lower {
+		return lower
+	}
+	if v > upper {
+		return upper
+	}
+	return v
+}
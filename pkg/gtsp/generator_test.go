@@ -0,0 +1,97 @@
+package gtsp
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// checks that every node belongs to exactly one cluster and returns cluster sizes
+func clusterSizes(t *testing.T, instance *Instance) []int {
+	seen := make([]bool, instance.NodeCount)
+	sizes := make([]int, instance.ClusterCount)
+	for cluster, nodes := range instance.Clusters() {
+		assert.True(t, len(nodes) > 0)
+		for _, node := range nodes {
+			assert.False(t, seen[node])
+			seen[node] = true
+		}
+		sizes[cluster] = len(nodes)
+	}
+	for _, s := range seen {
+		assert.True(t, s)
+	}
+	return sizes
+}
+
+func TestNewInstance_EqualClusters(t *testing.T) {
+	instance, err := NewInstance(23, 5, pkg.NewRand(1), WithClusters(EqualClusters))
+	assert.True(t, err == nil)
+
+	assert.Equal(t, []int{5, 5, 5, 4, 4}, clusterSizes(t, instance))
+}
+
+func TestNewInstance_PowerLawClusters(t *testing.T) {
+	instance, err := NewInstance(1000, 20, pkg.NewRand(1), WithClusters(PowerLawClusters))
+	assert.True(t, err == nil)
+
+	// the first cluster is expected to get about a quarter of all the nodes,
+	// the last one about 1/20 of that
+
+	sizes := clusterSizes(t, instance)
+	assert.True(t, sizes[0] > 150)
+	assert.True(t, sizes[19] < 50)
+}
+
+func TestNewInstance_GeographicClusters(t *testing.T) {
+	instance, err := NewInstance(200, 4, pkg.NewRand(1), WithClusters(GeographicClusters), WithMetric(Euclidean))
+	assert.True(t, err == nil)
+	clusterSizes(t, instance)
+
+	// nodes of a cluster are placed within a square with the side of 2 * 1000 / 8,
+	// so no two of them can be further apart than its diagonal
+
+	for _, nodes := range instance.Clusters() {
+		for _, a := range nodes {
+			for _, b := range nodes {
+				assert.True(t, instance.GetDistance(a, b) <= 354)
+			}
+		}
+	}
+}
+
+func TestNewInstance_UnknownDistribution(t *testing.T) {
+	_, err := NewInstance(20, 4, pkg.NewRand(1), WithClusters(ClusterDistribution(42)))
+	assert.EqualValues(t, "unknown cluster distribution", err.Error())
+
+	_, err = NewInstance(20, 4, pkg.NewRand(1), WithMetric(Metric(42)))
+	assert.EqualValues(t, "unknown metric", err.Error())
+}
+
+func TestParseClusterDistribution(t *testing.T) {
+	for _, d := range []ClusterDistribution{UniformClusters, EqualClusters, PowerLawClusters, GeographicClusters} {
+		parsed, err := ParseClusterDistribution(d.String())
+		assert.True(t, err == nil)
+		assert.Equal(t, d, parsed)
+	}
+
+	_, err := ParseClusterDistribution("normal")
+	assert.EqualValues(t, "unknown cluster distribution \"normal\", expected uniform, equal, power-law or geographic", err.Error())
+}
+
+func TestParseMetric(t *testing.T) {
+	metric, err := ParseMetric("euclidean")
+	assert.True(t, err == nil)
+	assert.Equal(t, Euclidean, metric)
+
+	_, err = ParseMetric("chebyshev")
+	assert.True(t, err != nil)
+}
+
+func Test_CalculateEuclideanDistance(t *testing.T) {
+	node1 := NodeCoord{3, 5}
+	node2 := NodeCoord{6, 9}
+
+	assert.EqualValues(t, 5, calculateEuclideanDistance(node1, node2))
+	assert.EqualValues(t, 1, calculateEuclideanDistance(NodeCoord{0, 0}, NodeCoord{1, 1}))
+}
@@ -0,0 +1,33 @@
+// Package gtsptest provides instances for tests of the packages working with GTSP
+package gtsptest
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+)
+
+// RandomInstance generates an instance with every parameter derived from the seed,
+// for property tests. the triangle inequality flag is random too, as only its value
+// being kept matters to them
+func RandomInstance(seed int64) *gtsp.Instance {
+	rnd := pkg.NewRand(seed)
+	nodeCount := 1 + rnd.GetRandomInteger(40)
+	clusterCount := 1 + rnd.GetRandomInteger(nodeCount)
+
+	distributions := gtsp.ClusterDistributions()
+	metrics := gtsp.Metrics()
+	options := []gtsp.Option{
+		gtsp.WithClusters(distributions[rnd.GetRandomInteger(len(distributions))]),
+		gtsp.WithMetric(metrics[rnd.GetRandomInteger(len(metrics))]),
+	}
+	if rnd.GetRandomInteger(2) == 0 {
+		options = append(options, gtsp.Asymmetric(rnd.GetRandomInteger(50)))
+	}
+
+	inst, err := gtsp.NewInstance(nodeCount, clusterCount, rnd.Split(), options...)
+	if err != nil {
+		panic(err)
+	}
+	inst.Triangle = rnd.GetRandomInteger(2) == 0
+	return inst
+}
@@ -12,20 +12,45 @@ type Instance struct {
 	Symmetric    bool
 	NodeCount    int
 	ClusterCount int
+	Seed         int64 // seed the instance was generated with, 0 if it wasn't generated
 	Distances    [][]int
-	Clusters     map[int][]int
+
+	// coordinates of the nodes are optional. when Distances is nil, distances
+	// are calculated from Coordinates on demand, using Metric
+	Coordinates []NodeCoord
+	Metric      Metric
+
+	// vertices of every cluster, only ever replaced as a whole by SetClusters, so
+	// that the index of the vertices built along with them is never out of date
+	clusters   map[int][]int
+	clusterOf  []int
+	positionOf []int
 }
 
 type NodeCoord struct {
-	x int
-	y int
+	X float64
+	Y float64
 }
 
-func NewInstance(nodeCount, clusterCount int) (*Instance, error) {
+func NewInstance(nodeCount, clusterCount int, rnd *pkg.Rand, options ...Option) (*Instance, error) {
 	if nodeCount < clusterCount {
 		return nil, errors.New("`node count` expected to be greater than `cluster count`")
 	}
 
+	config := generatorConfig{}
+	for _, option := range options {
+		option(&config)
+	}
+	if config.asymmetry < 0 {
+		return nil, errors.New("`asymmetry` expected to be non-negative")
+	}
+	if _, ok := clusterDistributionNames[config.clusters]; !ok {
+		return nil, errors.New("unknown cluster distribution")
+	}
+	if _, ok := metricNames[config.metric]; !ok {
+		return nil, errors.New("unknown metric")
+	}
+
 	// initialise Distances slice
 	w := make([][]int, nodeCount)
 	for i := range w {
@@ -37,11 +62,13 @@ func NewInstance(nodeCount, clusterCount int) (*Instance, error) {
 		Symmetric: true,
 		NodeCount:    nodeCount,
 		ClusterCount: clusterCount,
+		Seed:         rnd.Seed(),
 		Distances:    w,
-		Clusters:     make(map[int][]int),
+		clusters:     make(map[int][]int),
+		Metric:       config.metric,
 	}
 
-	instance.generateInstance()
+	instance.generateInstance(config, rnd)
 
 	return &instance, nil
 }
@@ -56,12 +83,24 @@ func (inst *Instance) PrintWeights() {
 }
 
 func (inst *Instance) GetInstanceName() string {
-	return fmt.Sprintf(`s%d-n%d-c%d`, pkg.Seed, inst.NodeCount, inst.ClusterCount)
+	return fmt.Sprintf(`s%d-n%d-c%d`, inst.Seed, inst.NodeCount, inst.ClusterCount)
 }
 
 func (inst *Instance) GetDistance(from, to int) int {
 
-	// since the graph isn't directional, we only save Distances from smaller node
+	// without the matrix the distance is calculated from the coordinates
+
+	if inst.Distances == nil {
+		return inst.Metric.distanceFunc()(inst.Coordinates[from], inst.Coordinates[to])
+	}
+
+	// asymmetric instances have a distance for each direction
+
+	if !inst.Symmetric {
+		return inst.Distances[from][to]
+	}
+
+	// since the graph isn't directional, we only read Distances from smaller node
 	// to higher node. vice versa has the same distance
 
 	if from < to {
@@ -70,12 +109,129 @@ func (inst *Instance) GetDistance(from, to int) int {
 	return inst.Distances[to][from]
 }
 
+func (inst *Instance) HasCoordinates() bool {
+	return len(inst.Coordinates) == inst.NodeCount && inst.NodeCount > 0
+}
+
+func (inst *Instance) DistanceMatrix() [][]int {
+
+	// returns a new matrix with the distance in both directions of every pair
+	// of nodes, as the matrix of a symmetric instance may have only its upper
+	// triangle filled, and an instance defined by coordinates may have none
+
+	dist := make([][]int, inst.NodeCount)
+	for i := range dist {
+		dist[i] = make([]int, inst.NodeCount)
+		for j := range dist[i] {
+			dist[i][j] = inst.GetDistance(i, j)
+		}
+	}
+	return dist
+}
+
+func (inst *Instance) CoordinatesDefineDistances() bool {
+
+	// tells whether every distance equals the one calculated from the coordinates,
+	// in which case the coordinates alone are enough to store the instance
+
+	if !inst.HasCoordinates() {
+		return false
+	}
+	if inst.Distances == nil {
+		return true
+	}
+	distance := inst.Metric.distanceFunc()
+	for i := 0; i < inst.NodeCount; i++ {
+		for j := 0; j < inst.NodeCount; j++ {
+			if i != j && inst.GetDistance(i, j) != distance(inst.Coordinates[i], inst.Coordinates[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (inst *Instance) ComputeDistances() {
+
+	// calculates and keeps the distance matrix of an instance defined by coordinates,
+	// which makes every following distance lookup a simple matrix access
+
+	if inst.Distances == nil {
+		inst.Distances = inst.DistanceMatrix()
+	}
+}
+
+// Equal tells whether both instances define the same problem, see Diff
+func (inst *Instance) Equal(other *Instance) bool {
+	return inst.Diff(other) == ""
+}
+
+// Diff describes the first difference between the instances, or returns an empty string
+// if there's none. distances are compared by their values, no matter if they're kept in
+// the matrix or calculated from the coordinates. Seed isn't compared, as it only tells
+// how the instance came to be
+func (inst *Instance) Diff(other *Instance) string {
+	if inst.NodeCount != other.NodeCount {
+		return fmt.Sprintf("NodeCount: %d != %d", inst.NodeCount, other.NodeCount)
+	}
+	if inst.ClusterCount != other.ClusterCount {
+		return fmt.Sprintf("ClusterCount: %d != %d", inst.ClusterCount, other.ClusterCount)
+	}
+	if inst.Symmetric != other.Symmetric {
+		return fmt.Sprintf("Symmetric: %t != %t", inst.Symmetric, other.Symmetric)
+	}
+	if inst.Triangle != other.Triangle {
+		return fmt.Sprintf("Triangle: %t != %t", inst.Triangle, other.Triangle)
+	}
+
+	// clusters are compared in the order of their indices, vertices in the listed order
+
+	for cluster := 0; cluster < inst.ClusterCount; cluster++ {
+		a, b := inst.clusters[cluster], other.clusters[cluster]
+		if len(a) != len(b) {
+			return fmt.Sprintf("cluster %d: %v != %v", cluster, a, b)
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return fmt.Sprintf("cluster %d: %v != %v", cluster, a, b)
+			}
+		}
+	}
+
+	if inst.HasCoordinates() != other.HasCoordinates() {
+		return fmt.Sprintf("Coordinates: %d nodes != %d nodes", len(inst.Coordinates), len(other.Coordinates))
+	}
+	if inst.HasCoordinates() {
+
+		// the metric matters only when the distances are calculated from the coordinates,
+		// otherwise the distance values below are what defines the instance
+
+		if inst.Metric != other.Metric && inst.Distances == nil && other.Distances == nil {
+			return fmt.Sprintf("Metric: %s != %s", inst.Metric, other.Metric)
+		}
+		for i := range inst.Coordinates {
+			if inst.Coordinates[i] != other.Coordinates[i] {
+				return fmt.Sprintf("coordinate of node %d: %v != %v", i, inst.Coordinates[i], other.Coordinates[i])
+			}
+		}
+	}
+
+	for i := 0; i < inst.NodeCount; i++ {
+		for j := 0; j < inst.NodeCount; j++ {
+			if a, b := inst.GetDistance(i, j), other.GetDistance(i, j); a != b {
+				return fmt.Sprintf("distance [%d][%d]: %d != %d", i, j, a, b)
+			}
+		}
+	}
+	return ""
+}
+
 func (inst *Instance) GetMinCluster() int {
 	minCluster := 0
 	minVertexNum := int(^uint(0) >> 1)
-	for cluster, vertices := range inst.Clusters {
-		if len(vertices) < minVertexNum {
-			minVertexNum = len(vertices)
+	for cluster := 0; cluster < inst.ClusterCount; cluster++ {
+		if len(inst.clusters[cluster]) < minVertexNum {
+			minVertexNum = len(inst.clusters[cluster])
 			minCluster = cluster
 		}
 	}
@@ -87,14 +243,75 @@ func (inst *Instance) VertexInCluster(v int) (int, error) {
 	// returns a clusters in which given vertex is placed/
 	// if no such vertex exists returns an error
 
-	for cluster, vertices := range inst.Clusters {
-		for _, vertex := range vertices {
-			if vertex == v {
-				return cluster, nil
+	cluster := inst.ClusterOf(v)
+	if cluster < 0 {
+		return 0, errors.New("no such vertex exists in any cluster")
+	}
+	return cluster, nil
+}
+
+// ClusterOf returns the cluster the vertex belongs to, or -1 if it isn't in any cluster
+func (inst *Instance) ClusterOf(v int) int {
+	if v < 0 || v >= len(inst.clusterOf) {
+		return -1
+	}
+	return inst.clusterOf[v]
+}
+
+// PositionInCluster returns the index of the vertex in the slice of its cluster,
+// or -1 if it isn't in any cluster
+func (inst *Instance) PositionInCluster(v int) int {
+	if v < 0 || v >= len(inst.positionOf) {
+		return -1
+	}
+	return inst.positionOf[v]
+}
+
+// Cluster returns the vertices of the cluster. the slice is shared with the instance
+// and must not be modified, SetClusters is the only way to change the clusters
+func (inst *Instance) Cluster(cluster int) []int {
+	return inst.clusters[cluster]
+}
+
+// Clusters returns a copy of the vertices of every cluster, by cluster
+func (inst *Instance) Clusters() map[int][]int {
+	return copyClusters(inst.clusters)
+}
+
+// SetClusters replaces the clusters of the instance with a copy of the given ones,
+// and indexes their vertices
+func (inst *Instance) SetClusters(clusters map[int][]int) {
+	inst.clusters = copyClusters(clusters)
+	inst.indexClusters()
+}
+
+// builds the index of the vertices. it's done whenever the clusters are set, so
+// the lookups only ever read it, even when the instance is shared between runs
+func (inst *Instance) indexClusters() {
+	inst.clusterOf = make([]int, inst.NodeCount)
+	inst.positionOf = make([]int, inst.NodeCount)
+	for v := range inst.clusterOf {
+		inst.clusterOf[v] = -1
+		inst.positionOf[v] = -1
+	}
+	for cluster, vertices := range inst.clusters {
+		for i, v := range vertices {
+			if v >= 0 && v < inst.NodeCount {
+				inst.clusterOf[v] = cluster
+				inst.positionOf[v] = i
 			}
 		}
 	}
-	return 0, errors.New("no such vertex exists in any cluster")
+}
+
+func copyClusters(clusters map[int][]int) map[int][]int {
+	cls := make(map[int][]int, len(clusters))
+	for k, v := range clusters {
+		nodes := make([]int, len(v))
+		copy(nodes, v)
+		cls[k] = nodes
+	}
+	return cls
 }
 
 func (inst *Instance) DeepCopy() *Instance {
@@ -102,96 +319,136 @@ func (inst *Instance) DeepCopy() *Instance {
 	// slice is a reference type, therefore we have to
 	// iterate over the og slice and copy values one by one
 
-	dist := make([][]int, inst.NodeCount)
-	for i := range dist {
-		dist[i] = make([]int, inst.NodeCount)
-		for j := range dist[i] {
-			dist[i][j] = inst.Distances[i][j]
+	var dist [][]int
+	if inst.Distances != nil {
+		dist = make([][]int, inst.NodeCount)
+		for i := range dist {
+			dist[i] = make([]int, inst.NodeCount)
+			for j := range dist[i] {
+				dist[i][j] = inst.Distances[i][j]
+			}
 		}
 	}
 
-	// same applies to map of Clusters
-
-	cls := make(map[int][]int)
-	for k, v := range inst.Clusters {
-		nodes := make([]int, len(v))
-		for i, _ := range v {
-			nodes[i] = v[i]
-		}
-		cls[k] = nodes
+	var coords []NodeCoord
+	if inst.Coordinates != nil {
+		coords = make([]NodeCoord, len(inst.Coordinates))
+		copy(coords, inst.Coordinates)
 	}
 
-	return &Instance{
+	cp := &Instance{
+		Triangle:     inst.Triangle,
+		Symmetric:    inst.Symmetric,
 		NodeCount:    inst.NodeCount,
 		ClusterCount: inst.ClusterCount,
+		Seed:         inst.Seed,
 		Distances:    dist,
-		Clusters:     cls,
+		Coordinates:  coords,
+		Metric:       inst.Metric,
 	}
+
+	// same applies to map of clusters, which is copied along with its index
+
+	cp.SetClusters(inst.clusters)
+	return cp
 }
 
-func (inst *Instance) generateInstance() {
+func (inst *Instance) generateInstance(config generatorConfig, rnd *pkg.Rand) {
+	var nodes []NodeCoord
+
+	if config.clusters == GeographicClusters {
+
+		// with geographic clustering the position of a node depends on its cluster,
+		// therefore nodes are distributed between Clusters first, and then placed
+		// around the centres of their Clusters
+
+		inst.generateClusters(config.clusters, rnd)
+		nodes = inst.generateGeographicCoordinates(rnd)
+	} else {
 
-	// first create a plane with random coordinates
-	// for each node
+		// first create a plane with random coordinates
+		// for each node
 
-	nodes := inst.generateCoordinates()
+		nodes = inst.generateCoordinates(rnd)
+
+		// generate Clusters and distribute the nodes between
+		// these Clusters. distribution is up to the selected
+		// cluster distribution and the random number generator
+
+		inst.generateClusters(config.clusters, rnd)
+	}
 
 	// now calculates the distance (Distances) between
 	// each node
 
-	inst.calculateDistances(nodes)
+	inst.Coordinates = nodes
+	inst.calculateDistances(nodes, config.metric)
 
-	// generate Clusters and distribute the nodes between
-	// these Clusters. distribution is up to the random
-	// number generator
-	// TODO: implement different kinds of distributions?
+	// optionally make the distance depend on the direction
 
-	inst.generateClusters()
+	if config.asymmetry > 0 {
+		inst.makeAsymmetric(config.asymmetry, rnd)
+	}
+
+	// Manhattan distances between integer coordinates are exact, therefore they
+	// satisfy the triangle inequality. rounded Euclidean distances may not
+
+	inst.Triangle = config.metric == Manhattan && config.asymmetry == 0
+	if config.closure {
+		inst.MetricClosure()
+	}
+
+	inst.indexClusters()
 }
 
-func (inst *Instance) generateCoordinates() []NodeCoord {
+func (inst *Instance) generateCoordinates(rnd *pkg.Rand) []NodeCoord {
 	var nodes = make([]NodeCoord, inst.NodeCount)
 	for i := 0; i < inst.NodeCount; i++ {
 		coordinate := NodeCoord{
-			x: pkg.GetRandomInteger(inst.NodeCount * 5),
-			y: pkg.GetRandomInteger(inst.NodeCount * 5),
+			X: float64(rnd.GetRandomInteger(inst.planeSize())),
+			Y: float64(rnd.GetRandomInteger(inst.planeSize())),
 		}
 		nodes[i] = coordinate
 	}
 	return nodes
 }
 
-func (inst *Instance) calculateDistances(nodes []NodeCoord) {
+func (inst *Instance) calculateDistances(nodes []NodeCoord, metric Metric) {
+	distance := metric.distanceFunc()
 	for i := 0; i < inst.NodeCount; i++ {
 		for j := i + 1; j < inst.NodeCount; j++ {
-			distance := calculateDistance(nodes[i], nodes[j])
-			inst.Distances[i][j] = distance
-			inst.Distances[j][i] = distance
+			d := distance(nodes[i], nodes[j])
+			inst.Distances[i][j] = d
+			inst.Distances[j][i] = d
 		}
 	}
 }
 
-func (inst *Instance) generateClusters() {
-
-	// add a single node to each cluster to ensure
-	// there's at least one node
-
-	for i := 0; i < inst.ClusterCount; i++ {
-		inst.Clusters[i] = []int{i}
+func (inst *Instance) makeAsymmetric(percent int, rnd *pkg.Rand) {
+	for i := 0; i < inst.NodeCount; i++ {
+		for j := 0; j < inst.NodeCount; j++ {
+			if i != j {
+				inst.Distances[i][j] += rnd.GetRandomInteger(inst.Distances[i][j]*percent/100 + 1)
+			}
+		}
 	}
+	inst.Symmetric = false
+}
 
-	// distribute remaining nodes randomly between Clusters
-
-	for i := inst.ClusterCount; i < inst.NodeCount; i++ {
-		cluster := pkg.GetRandomInteger(inst.ClusterCount)
-		inst.Clusters[cluster] = append(inst.Clusters[cluster], i)
-	}
+// nodes are placed on a square plane with the side proportional to the node count
+func (inst *Instance) planeSize() int {
+	return inst.NodeCount * 5
 }
 
-// calculates Manhattan distance between 2 coordinates
+// calculates Manhattan distance between 2 coordinates, rounded to the nearest integer
 func calculateDistance(c1, c2 NodeCoord) int {
-	if c1.x == c2.x && c1.y == c2.y {
+	if c1.X == c2.X && c1.Y == c2.Y {
 		return 0
 	}
-	return int(math.Abs(float64(c1.x-c2.x)) + math.Abs(float64(c1.y-c2.y)))
+	return int(math.Abs(c1.X-c2.X) + math.Abs(c1.Y-c2.Y) + 0.5)
+}
+
+// calculates Euclidean distance between 2 coordinates, rounded to the nearest integer
+func calculateEuclideanDistance(c1, c2 NodeCoord) int {
+	return int(math.Hypot(c1.X-c2.X, c1.Y-c2.Y) + 0.5)
 }
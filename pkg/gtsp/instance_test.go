@@ -1,25 +1,27 @@
 package gtsp
 
 import (
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func TestNewInstanceClusterCountGreaterThanNodeCount(t *testing.T) {
-	_, err := NewInstance(3, 10)
+	_, err := NewInstance(3, 10, pkg.NewRand(1))
 	assert.Equal(t, "`node count` expected to be greater than `cluster count`", err.Error())
 }
 
 func TestNewInstanceHappyPath(t *testing.T) {
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 	assert.EqualValues(t, 10, instance.NodeCount)
 	assert.EqualValues(t, 3, instance.ClusterCount)
-	assert.True(t, len(instance.Clusters) == instance.ClusterCount)
+	assert.True(t, len(instance.Clusters()) == instance.ClusterCount)
 }
 
 func TestInstance_GetInstanceName(t *testing.T) {
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 	assert.EqualValues(t, "s1-n10-c3", instance.GetInstanceName())
 }
@@ -31,7 +33,7 @@ func TestInstance_GetDistance(t *testing.T) {
 		{0, 0, 0},
 	}
 
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
 	instance.Distances = weights
@@ -40,6 +42,66 @@ func TestInstance_GetDistance(t *testing.T) {
 	assert.EqualValues(t, 4, instance.GetDistance(2, 1))
 }
 
+func TestInstance_GetDistanceFromCoordinates(t *testing.T) {
+	instance := &Instance{
+		NodeCount:   3,
+		Symmetric:   true,
+		Coordinates: []NodeCoord{{0, 0}, {3, 4}, {6, 8}},
+		Metric:      Euclidean,
+	}
+
+	// with no distance matrix, distances are calculated from the coordinates
+
+	assert.EqualValues(t, 5, instance.GetDistance(0, 1))
+	assert.EqualValues(t, 10, instance.GetDistance(2, 0))
+	assert.True(t, instance.CoordinatesDefineDistances())
+
+	instance.ComputeDistances()
+	assert.Equal(t, [][]int{{0, 5, 10}, {5, 0, 5}, {10, 5, 0}}, instance.Distances)
+
+	// a changed distance can no longer be derived from the coordinates
+
+	instance.Distances[0][1] = 7
+	assert.False(t, instance.CoordinatesDefineDistances())
+}
+
+func TestInstance_GetDistanceAsymmetric(t *testing.T) {
+	weights := [][]int{
+		{0, 2, 3},
+		{5, 0, 4},
+		{6, 7, 0},
+	}
+
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	instance.Distances = weights
+	instance.Symmetric = false
+
+	assert.EqualValues(t, 4, instance.GetDistance(1, 2))
+	assert.EqualValues(t, 7, instance.GetDistance(2, 1))
+}
+
+func TestNewInstanceAsymmetric(t *testing.T) {
+	instance, err := NewInstance(20, 4, pkg.NewRand(1), Asymmetric(50))
+	assert.True(t, err == nil)
+	assert.False(t, instance.Symmetric)
+
+	differs := false
+	for i := 0; i < instance.NodeCount; i++ {
+		assert.EqualValues(t, 0, instance.Distances[i][i])
+		for j := 0; j < instance.NodeCount; j++ {
+			if instance.Distances[i][j] != instance.Distances[j][i] {
+				differs = true
+			}
+		}
+	}
+	assert.True(t, differs)
+
+	_, err = NewInstance(20, 4, pkg.NewRand(1), Asymmetric(-1))
+	assert.EqualValues(t, "`asymmetry` expected to be non-negative", err.Error())
+}
+
 func TestInstance_GetMinCluster(t *testing.T) {
 	clusters := map[int][]int{
 		0: {1, 2, 3},
@@ -47,15 +109,15 @@ func TestInstance_GetMinCluster(t *testing.T) {
 		2: {5, 6, 7, 8},
 	}
 
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	instance.Clusters = clusters
+	instance.SetClusters(clusters)
 	assert.EqualValues(t, 1, instance.GetMinCluster())
 }
 
 func TestInstance_VertexInCluster_VertexDoesNotExists(t *testing.T) {
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
 	_, err = instance.VertexInCluster(22)
@@ -64,19 +126,77 @@ func TestInstance_VertexInCluster_VertexDoesNotExists(t *testing.T) {
 }
 
 func TestInstance_DeepCopy(t *testing.T) {
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
 	deepCopy := instance.DeepCopy()
 
 	assert.Equal(t, *instance, *deepCopy)
 	assert.False(t, &instance == &deepCopy)
-	assert.False(t, &instance.Clusters == &deepCopy.Clusters)
+	assert.False(t, &instance.Cluster(0)[0] == &deepCopy.Cluster(0)[0])
 	assert.False(t, &instance.Distances == &deepCopy.Distances)
 	assert.False(t, &instance.NodeCount == &deepCopy.NodeCount)
 	assert.False(t, &instance.ClusterCount == &deepCopy.ClusterCount)
 }
 
+func TestInstance_Diff(t *testing.T) {
+	instance, err := NewInstance(10, 3, pkg.NewRand(1), Asymmetric(20))
+	assert.True(t, err == nil)
+
+	cases := []struct {
+		change   func(inst *Instance)
+		expected string
+	}{
+		{func(inst *Instance) { inst.Seed++ }, ""},
+		{func(inst *Instance) { inst.Symmetric = true }, "Symmetric: false != true"},
+		{func(inst *Instance) { inst.Triangle = true }, "Triangle: false != true"},
+		{func(inst *Instance) { inst.ClusterCount = 4 }, "ClusterCount: 3 != 4"},
+		{
+			func(inst *Instance) {
+				clusters := inst.Clusters()
+				clusters[1] = append(clusters[1], 11)
+				inst.SetClusters(clusters)
+			},
+			fmt.Sprintf("cluster 1: %v != %v", instance.Cluster(1), append(append([]int{}, instance.Cluster(1)...), 11)),
+		},
+		{
+			func(inst *Instance) { inst.Coordinates[4] = NodeCoord{-1, -1} },
+			fmt.Sprintf("coordinate of node 4: %v != {-1 -1}", instance.Coordinates[4]),
+		},
+		{
+			func(inst *Instance) { inst.Distances[2][7] = 1000 },
+			fmt.Sprintf("distance [2][7]: %d != 1000", instance.Distances[2][7]),
+		},
+	}
+
+	for _, c := range cases {
+		other := instance.DeepCopy()
+		c.change(other)
+		assert.Equal(t, c.expected, instance.Diff(other))
+		assert.Equal(t, c.expected == "", instance.Equal(other))
+	}
+}
+
+func TestInstance_DiffComparesDistanceValues(t *testing.T) {
+	instance, err := NewInstance(10, 3, pkg.NewRand(1), WithMetric(Euclidean))
+	assert.True(t, err == nil)
+
+	// distances calculated on demand are the same as the ones kept in the matrix
+
+	lazy := instance.DeepCopy()
+	lazy.Distances = nil
+	assert.Equal(t, "", instance.Diff(lazy))
+
+	// with both calculated on demand the metrics are compared, otherwise the distances are
+
+	other := lazy.DeepCopy()
+	other.Metric = Manhattan
+	assert.Equal(t, "Metric: euclidean != manhattan", lazy.Diff(other))
+
+	other.ComputeDistances()
+	assert.Equal(t, fmt.Sprintf("distance [0][1]: %d != %d", lazy.GetDistance(0, 1), other.GetDistance(0, 1)), lazy.Diff(other))
+}
+
 func TestInstance_VertexInCluster(t *testing.T) {
 	clusters := map[int][]int{
 		0: {1, 2, 3},
@@ -84,10 +204,10 @@ func TestInstance_VertexInCluster(t *testing.T) {
 		2: {5, 6, 7, 8},
 	}
 
-	instance, err := NewInstance(10, 3)
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	instance.Clusters = clusters
+	instance.SetClusters(clusters)
 
 	cluster, err := instance.VertexInCluster(4)
 
@@ -95,8 +215,75 @@ func TestInstance_VertexInCluster(t *testing.T) {
 	assert.EqualValues(t, 1, cluster)
 }
 
+func TestInstance_ClusterOf(t *testing.T) {
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// the index is built by the generator
+
+	for cluster, vertices := range instance.Clusters() {
+		for i, v := range vertices {
+			assert.Equal(t, cluster, instance.ClusterOf(v))
+			assert.Equal(t, i, instance.PositionInCluster(v))
+		}
+	}
+	assert.Equal(t, -1, instance.ClusterOf(10))
+	assert.Equal(t, -1, instance.PositionInCluster(-1))
+}
+
+func TestInstance_ClusterOfAfterClustersReplaced(t *testing.T) {
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// vertex 0 is left out of every cluster
+
+	instance.SetClusters(map[int][]int{
+		0: {1, 2, 3},
+		1: {9, 4},
+		2: {5, 6, 7, 8},
+	})
+	assert.Equal(t, 1, instance.ClusterOf(4))
+	assert.Equal(t, 1, instance.PositionInCluster(4))
+	assert.Equal(t, -1, instance.ClusterOf(0))
+
+	instance.SetClusters(map[int][]int{0: {0, 1, 2, 3, 4}, 1: {5}, 2: {6, 7, 8, 9}})
+	assert.Equal(t, 0, instance.ClusterOf(4))
+	assert.Equal(t, 4, instance.PositionInCluster(4))
+
+	// the instance keeps its own copy, so changing the maps given to it or returned
+	// by it can't leave the index out of date
+
+	clusters := instance.Clusters()
+	clusters[1] = []int{5, 4}
+	clusters[0] = clusters[0][:4]
+	assert.Equal(t, []int{5}, instance.Cluster(1))
+	assert.Equal(t, 0, instance.ClusterOf(4))
+
+	instance.SetClusters(clusters)
+	clusters[1][1] = 0
+	assert.Equal(t, []int{5, 4}, instance.Cluster(1))
+	assert.Equal(t, 1, instance.ClusterOf(4))
+	assert.Equal(t, 1, instance.PositionInCluster(4))
+}
+
+func TestInstance_DeepCopyIndexesClusters(t *testing.T) {
+	instance, err := NewInstance(10, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	cp := instance.DeepCopy()
+	clusters := cp.Clusters()
+	clusters[0], clusters[1] = clusters[1], clusters[0]
+	cp.SetClusters(clusters)
+
+	// the original index isn't affected by the copy
+
+	v := instance.Cluster(0)[0]
+	assert.Equal(t, 0, instance.ClusterOf(v))
+	assert.Equal(t, 1, cp.ClusterOf(v))
+}
+
 func TestInstance_CalculateDistances(t *testing.T) {
-	instance, err := NewInstance(3, 1)
+	instance, err := NewInstance(3, 1, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
 	nodes := []NodeCoord{
@@ -105,12 +292,14 @@ func TestInstance_CalculateDistances(t *testing.T) {
 		{2, 9},
 	}
 
-	instance.calculateDistances(nodes)
+	instance.calculateDistances(nodes, Manhattan)
+
+	// the matrix is filled in both directions, as the exporter writes it in full
 
 	expectedDistances := [][]int{
 		{0, 3, 3},
-		{0, 0, 4},
-		{0, 0, 0},
+		{3, 0, 4},
+		{3, 4, 0},
 	}
 
 	assert.EqualValues(t, instance.Distances, expectedDistances)
@@ -126,8 +315,8 @@ func Test_CalculateDistance(t *testing.T) {
 }
 
 func TestInstance_GenerateClustersHasAtLeastASingleNodeInCluster(t *testing.T) {
-	instance, err := NewInstance(3, 2)
+	instance, err := NewInstance(3, 2, pkg.NewRand(1))
 	assert.True(t, err == nil)
-	assert.True(t, len(instance.Clusters[0]) > 0)
-	assert.True(t, len(instance.Clusters[1]) > 0)
+	assert.True(t, len(instance.Cluster(0)) > 0)
+	assert.True(t, len(instance.Cluster(1)) > 0)
 }
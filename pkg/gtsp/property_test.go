@@ -0,0 +1,19 @@
+package gtsp_test
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg/gtsp/gtsptest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/quick"
+)
+
+// the property tests live in an external test package, as gtsptest depends on gtsp
+
+func TestInstance_DeepCopyProperty(t *testing.T) {
+	property := func(seed int64) bool {
+		inst := gtsptest.RandomInstance(seed)
+		cp := inst.DeepCopy()
+		return inst.Equal(cp) && cp.Equal(inst)
+	}
+	assert.True(t, quick.Check(property, nil) == nil)
+}
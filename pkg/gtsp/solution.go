@@ -1,18 +1,20 @@
 package gtsp
 
 import (
+	"errors"
+	"fmt"
 	"github.com/olegnalivajev/cmcs/pkg"
 )
 
 type Solution struct {
-	Instance    Instance
+	Instance    *Instance // shared between solutions of the instance, never modified by them
 	Distance    int
 	Vertices    []int // vertex in cluster(index)
 	PrevCluster []int // cluster (value) preceding cluster (index)
 	NextCluster []int // cluster (value) succeeding cluster (index)
 }
 
-func GenerateSolution(instance Instance) *Solution {
+func GenerateSolution(instance *Instance, rnd *pkg.Rand) *Solution {
 	solution := Solution{
 		Instance:    instance,
 		Distance:    0,
@@ -20,11 +22,70 @@ func GenerateSolution(instance Instance) *Solution {
 		PrevCluster: make([]int, instance.ClusterCount),
 		NextCluster: make([]int, instance.ClusterCount),
 	}
-	solution.generateInitialSolution()
+	solution.generateInitialSolution(rnd)
 	solution.CalculateDistance()
 	return &solution
 }
 
+func SolutionFromTour(instance *Instance, tour []int) (*Solution, error) {
+
+	// builds a solution from vertices listed in the visiting order. the tour
+	// has to visit every cluster exactly once
+
+	if len(tour) != instance.ClusterCount {
+		return nil, fmt.Errorf("tour has %d vertices, expected %d", len(tour), instance.ClusterCount)
+	}
+
+	solution := Solution{
+		Instance:    instance,
+		Distance:    0,
+		Vertices:    make([]int, instance.ClusterCount),
+		PrevCluster: make([]int, instance.ClusterCount),
+		NextCluster: make([]int, instance.ClusterCount),
+	}
+
+	clusters := make([]int, len(tour))
+	visited := make([]bool, instance.ClusterCount)
+	for i, v := range tour {
+		cluster, err := instance.VertexInCluster(v)
+		if err != nil {
+			return nil, fmt.Errorf("vertex %d: %v", v, err)
+		}
+		if visited[cluster] {
+			return nil, fmt.Errorf("cluster %d is visited more than once", cluster)
+		}
+		visited[cluster] = true
+		clusters[i] = cluster
+		solution.Vertices[cluster] = v
+	}
+
+	for i, cluster := range clusters {
+		next := clusters[(i+1)%len(clusters)]
+		solution.NextCluster[cluster] = next
+		solution.PrevCluster[next] = cluster
+	}
+
+	if !solution.IsFeasible() {
+		return nil, errors.New("tour is not a feasible solution")
+	}
+
+	solution.CalculateDistance()
+	return &solution, nil
+}
+
+func (s *Solution) Tour() []int {
+
+	// returns visited vertices in the visiting order, starting from cluster 0
+
+	tour := make([]int, 0, s.Instance.ClusterCount)
+	cluster := 0
+	for i := 0; i < s.Instance.ClusterCount; i++ {
+		tour = append(tour, s.Vertices[cluster])
+		cluster = s.NextCluster[cluster]
+	}
+	return tour
+}
+
 func (s *Solution) UpdateDistance(amount int) {
 	s.Distance += amount
 }
@@ -44,9 +105,16 @@ func (s *Solution) InsertCluster(cluster, afterCluster int) {
 	// likewise, z no longer points to next[z], but to x, and prev[next[z]]
 	// no longer points to z but to x
 
-	// first update pointers to previous and next Clusters
+	// inserting a cluster after itself or after its preceding cluster
+	// leaves the tour as it is
 
 	before := s.PrevCluster[cluster]
+	if afterCluster == cluster || afterCluster == before {
+		return
+	}
+
+	// first update pointers to previous and next Clusters
+
 	after := s.NextCluster[cluster]
 	between := s.NextCluster[afterCluster]
 
@@ -74,27 +142,237 @@ func (s *Solution) InsertCluster(cluster, afterCluster int) {
 	s.Distance += s.Instance.GetDistance(clusterVertex, newAfterVertex)
 }
 
-func (s *Solution) SwapVertexInCluster(cluster int) {
+func (s *Solution) InsertClusterDelta(cluster, afterCluster int) int {
 
-	// swaps current vertex in cluster to another random vertex from the same cluster.
-	// the swap is guaranteed, unless cluster is of size 1
+	// returns the change of the distance InsertCluster(cluster, afterCluster) would
+	// cause, without modifying the solution. inserting a cluster after itself or after
+	// its preceding cluster doesn't change the tour
+
+	before := s.PrevCluster[cluster]
+	if afterCluster == cluster || afterCluster == before {
+		return 0
+	}
+	after := s.NextCluster[cluster]
+	between := s.NextCluster[afterCluster]
+
+	clusterVertex := s.Vertices[cluster]
+	beforeVertex := s.Vertices[before]
+	afterVertex := s.Vertices[after]
+	newBeforeVertex := s.Vertices[afterCluster]
+	newAfterVertex := s.Vertices[between]
 
-	if len(s.Instance.Clusters[cluster]) == 1 {
+	return s.Instance.GetDistance(beforeVertex, afterVertex) +
+		s.Instance.GetDistance(newBeforeVertex, clusterVertex) +
+		s.Instance.GetDistance(clusterVertex, newAfterVertex) -
+		s.Instance.GetDistance(beforeVertex, clusterVertex) -
+		s.Instance.GetDistance(clusterVertex, afterVertex) -
+		s.Instance.GetDistance(newBeforeVertex, newAfterVertex)
+}
+
+func (s *Solution) SwapClusters(a, b int) {
+
+	// exchanges the positions of clusters a and b in the tour, i.e. x -> a -> y and
+	// z -> b -> w become x -> b -> y and z -> a -> w. adjacent clusters are swapped
+	// as x -> a -> b -> w becoming x -> b -> a -> w
+
+	delta := s.SwapClustersDelta(a, b)
+	if a == b || s.Instance.ClusterCount < 3 {
 		return
 	}
 
-	rndIndex := pkg.GetRandomInteger(len(s.Instance.Clusters[cluster]))
-	newVertex := s.Instance.Clusters[cluster][rndIndex]
+	// order adjacent clusters, so that b always follows a
+
+	if s.NextCluster[b] == a {
+		a, b = b, a
+	}
+
+	before := s.PrevCluster[a]
+	after := s.NextCluster[b]
+
+	if s.NextCluster[a] == b {
+		s.NextCluster[before] = b
+		s.PrevCluster[b] = before
+		s.NextCluster[b] = a
+		s.PrevCluster[a] = b
+		s.NextCluster[a] = after
+		s.PrevCluster[after] = a
+	} else {
+		afterA := s.NextCluster[a]
+		beforeB := s.PrevCluster[b]
+
+		s.NextCluster[before] = b
+		s.PrevCluster[b] = before
+		s.NextCluster[b] = afterA
+		s.PrevCluster[afterA] = b
+		s.NextCluster[beforeB] = a
+		s.PrevCluster[a] = beforeB
+		s.NextCluster[a] = after
+		s.PrevCluster[after] = a
+	}
+
+	s.Distance += delta
+}
+
+func (s *Solution) SwapClustersDelta(a, b int) int {
+
+	// returns the change of the distance SwapClusters(a, b) would cause, without
+	// modifying the solution. with fewer than 3 clusters the tour stays the same
+
+	if a == b || s.Instance.ClusterCount < 3 {
+		return 0
+	}
+	if s.NextCluster[b] == a {
+		a, b = b, a
+	}
+
+	va, vb := s.Vertices[a], s.Vertices[b]
+	before := s.Vertices[s.PrevCluster[a]]
+	after := s.Vertices[s.NextCluster[b]]
 
-	// if we have selected the same vertex, we gonna call a function again,
-	// until a different vertex is selected
+	// adjacent clusters share an edge, which only changes its direction
 
-	if newVertex == s.Vertices[cluster] {
-		s.SwapVertexInCluster(cluster)
+	if s.NextCluster[a] == b {
+		return s.Instance.GetDistance(before, vb) + s.Instance.GetDistance(vb, va) + s.Instance.GetDistance(va, after) -
+			s.Instance.GetDistance(before, va) - s.Instance.GetDistance(va, vb) - s.Instance.GetDistance(vb, after)
+	}
+
+	afterA := s.Vertices[s.NextCluster[a]]
+	beforeB := s.Vertices[s.PrevCluster[b]]
+
+	return s.Instance.GetDistance(before, vb) + s.Instance.GetDistance(vb, afterA) +
+		s.Instance.GetDistance(beforeB, va) + s.Instance.GetDistance(va, after) -
+		s.Instance.GetDistance(before, va) - s.Instance.GetDistance(va, afterA) -
+		s.Instance.GetDistance(beforeB, vb) - s.Instance.GetDistance(vb, after)
+}
+
+func (s *Solution) MoveSegment(first, last, afterCluster int, reversed bool) {
+
+	// the segment of consecutive clusters going from `first` to `last` is extracted
+	// and inserted after cluster z, optionally reversed. i.e. a -> first -> x -> last -> b
+	// and z -> c become a -> b and z -> first -> x -> last -> c. `afterCluster` must not
+	// be a part of the segment. inserting the segment after its preceding cluster
+	// leaves it in place, only reversing it if asked to
+
+	before := s.PrevCluster[first]
+	if afterCluster == before {
+		if reversed {
+			s.ReverseSegment(first, last)
+		}
 		return
 	}
+	after := s.NextCluster[last]
+	between := s.NextCluster[afterCluster]
+
+	s.NextCluster[before] = after
+	s.PrevCluster[after] = before
+	s.NextCluster[afterCluster] = first
+	s.PrevCluster[first] = afterCluster
+	s.NextCluster[last] = between
+	s.PrevCluster[between] = last
+
+	// only the three edges at the ends of the segment and of its new position change
+
+	s.Distance -= s.Instance.GetDistance(s.Vertices[before], s.Vertices[first])
+	s.Distance -= s.Instance.GetDistance(s.Vertices[last], s.Vertices[after])
+	s.Distance -= s.Instance.GetDistance(s.Vertices[afterCluster], s.Vertices[between])
+	s.Distance += s.Instance.GetDistance(s.Vertices[before], s.Vertices[after])
+	s.Distance += s.Instance.GetDistance(s.Vertices[afterCluster], s.Vertices[first])
+	s.Distance += s.Instance.GetDistance(s.Vertices[last], s.Vertices[between])
+
+	if reversed {
+		s.ReverseSegment(first, last)
+	}
+}
+
+func (s *Solution) ReverseSegment(first, last int) {
+
+	// reverses the part of the tour going from cluster `first` to cluster `last`.
+	// i.e. a -> first -> x -> last -> b becomes a -> last -> x -> first -> b.
+	// pointers of every cluster in the segment are swapped, and the edges inside
+	// the segment change their direction, which matters for asymmetric instances
+
+	before := s.PrevCluster[first]
+	after := s.NextCluster[last]
+
+	delta := 0
+	for cluster := first; ; {
+		next := s.NextCluster[cluster]
+		if cluster != last || after == first {
+			delta += s.Instance.GetDistance(s.Vertices[next], s.Vertices[cluster])
+			delta -= s.Instance.GetDistance(s.Vertices[cluster], s.Vertices[next])
+		}
+		s.PrevCluster[cluster], s.NextCluster[cluster] = s.NextCluster[cluster], s.PrevCluster[cluster]
+		if cluster == last {
+			break
+		}
+		cluster = next
+	}
+
+	// when the segment covers the whole tour, the entire cycle simply changes
+	// its direction. otherwise the segment has to be reconnected to the rest
+
+	if after != first {
+		s.NextCluster[before] = last
+		s.PrevCluster[last] = before
+		s.NextCluster[first] = after
+		s.PrevCluster[after] = first
+
+		delta += s.Instance.GetDistance(s.Vertices[before], s.Vertices[last])
+		delta += s.Instance.GetDistance(s.Vertices[first], s.Vertices[after])
+		delta -= s.Instance.GetDistance(s.Vertices[before], s.Vertices[first])
+		delta -= s.Instance.GetDistance(s.Vertices[last], s.Vertices[after])
+	}
 
-	s.Vertices[cluster] = newVertex
+	s.Distance += delta
+}
+
+func (s *Solution) SwapVertexInCluster(cluster int, rnd *pkg.Rand) int {
+
+	// swaps current vertex in cluster to another random vertex from the same cluster.
+	// the swap is guaranteed, unless cluster is of size 1. returns the gain of the swap
+
+	if len(s.Instance.Cluster(cluster)) == 1 {
+		return 0
+	}
+
+	// select among the other vertices only, skipping over the position
+	// of the current vertex, so a single draw is always enough
+
+	vertices := s.Instance.Cluster(cluster)
+	rndIndex := rnd.GetRandomInteger(len(vertices) - 1)
+	if rndIndex >= s.Instance.PositionInCluster(s.Vertices[cluster]) {
+		rndIndex++
+	}
+
+	return s.SetVertex(cluster, vertices[rndIndex])
+}
+
+func (s *Solution) SetVertex(cluster, vertex int) int {
+
+	// replaces the vertex visited in the cluster. the distance is updated by replacing
+	// the edges incident to the old vertex with the edges incident to the new one.
+	// returns the gain, i.e. old distance - new distance
+
+	oldVertex := s.Vertices[cluster]
+
+	// a tour of a single cluster is a loop, there are no neighbours to replace edges with
+
+	if s.Instance.ClusterCount == 1 {
+		before := s.Distance
+		s.Vertices[cluster] = vertex
+		s.CalculateDistance()
+		return before - s.Distance
+	}
+
+	prevVertex := s.Vertices[s.PrevCluster[cluster]]
+	nextVertex := s.Vertices[s.NextCluster[cluster]]
+
+	delta := s.Instance.GetDistance(prevVertex, vertex) + s.Instance.GetDistance(vertex, nextVertex) -
+		s.Instance.GetDistance(prevVertex, oldVertex) - s.Instance.GetDistance(oldVertex, nextVertex)
+
+	s.Vertices[cluster] = vertex
+	s.Distance += delta
+	return -delta
 }
 
 func (s *Solution) IsFeasible() bool {
@@ -102,13 +380,7 @@ func (s *Solution) IsFeasible() bool {
 	// check if vertex actually exists in the corresponding cluster
 
 	for i, v := range s.Vertices {
-		found := false
-		for _, vertex := range s.Instance.Clusters[i] {
-			if v == vertex {
-				found = true
-			}
-		}
-		if !found {
+		if s.Instance.ClusterOf(v) != i {
 			return false
 		}
 	}
@@ -134,6 +406,9 @@ func (s *Solution) IsFeasible() bool {
 
 func (s *Solution) DeepCopy() *Solution {
 
+	// the instance is read-only, so only the tour is copied and the copy
+	// refers to the same instance
+
 	vrt := make([]int, len(s.Vertices))
 	copy(vrt, s.Vertices)
 
@@ -144,7 +419,7 @@ func (s *Solution) DeepCopy() *Solution {
 	copy(next, s.NextCluster)
 
 	return &Solution{
-		Instance:    *s.Instance.DeepCopy(),
+		Instance:    s.Instance,
 		Distance:    s.Distance,
 		Vertices:    vrt,
 		PrevCluster: prev,
@@ -153,7 +428,27 @@ func (s *Solution) DeepCopy() *Solution {
 
 }
 
-func (s *Solution) generateInitialSolution() {
+func (s *Solution) generateInitialSolution(rnd *pkg.Rand) {
+
+	// a single cluster follows and precedes itself, otherwise the clusters are
+	// put in a random order
+
+	if s.Instance.ClusterCount == 1 {
+		s.NextCluster[0] = 0
+		s.PrevCluster[0] = 0
+	} else {
+		s.generateClusterOrder(rnd)
+	}
+
+	// select a random node from each cluster
+
+	for i := 0; i < s.Instance.ClusterCount; i++ {
+		rndIndex := len(s.Instance.Cluster(i))
+		s.Vertices[i] = s.Instance.Cluster(i)[rnd.GetRandomInteger(rndIndex)]
+	}
+}
+
+func (s *Solution) generateClusterOrder(rnd *pkg.Rand) {
 
 	clusters := make([]int, s.Instance.ClusterCount-1)
 
@@ -167,11 +462,11 @@ func (s *Solution) generateInitialSolution() {
 	// remove it from available Clusters, however it's now recorded as current cluster
 	// so we can iteratively pick random Clusters to follow the current one
 
-	rnd := pkg.GetRandomInteger(len(clusters))
-	curr := clusters[rnd]
+	index := rnd.GetRandomInteger(len(clusters))
+	curr := clusters[index]
 	s.NextCluster[0] = curr
 	s.PrevCluster[curr] = 0
-	clusters = remove(clusters, rnd)
+	clusters = remove(clusters, index)
 
 	// iteratively pick a next cluster that will follow the current one.
 	// since we have already found a cluster succeeding cluster 0, and cluster 0
@@ -180,26 +475,18 @@ func (s *Solution) generateInitialSolution() {
 	// the iteration starts from i = 2
 
 	for i := 2; i < s.Instance.ClusterCount; i++ {
-		rnd = pkg.GetRandomInteger(len(clusters))
-		cluster := clusters[rnd]
+		index = rnd.GetRandomInteger(len(clusters))
+		cluster := clusters[index]
 		s.NextCluster[curr] = cluster
 		s.PrevCluster[cluster] = curr
 		curr = cluster
-		clusters = remove(clusters, rnd)
+		clusters = remove(clusters, index)
 	}
 
 	// at this point `curr` cluster is the last cluster we processed, so cluster 0
 	// will follow it, therefore the preceding cluster to cluster 0 is `curr` cluster
 
 	s.PrevCluster[0] = curr
-
-	// select a random node from each cluster
-
-	for i := 0; i < s.Instance.ClusterCount; i++ {
-		rndIndex := len(s.Instance.Clusters[i])
-		s.Vertices[i] = s.Instance.Clusters[i][pkg.GetRandomInteger(rndIndex)]
-	}
-
 }
 
 // removes an int element from a slice with no duplicates
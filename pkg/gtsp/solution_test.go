@@ -1,21 +1,22 @@
 package gtsp
 
 import (
+	"github.com/olegnalivajev/cmcs/pkg"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func TestSolution_InsertCluster(t *testing.T) {
-	inst, err := NewInstance(5, 5)
+	inst, err := NewInstance(5, 5, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	inst.Clusters = map[int][]int{
+	inst.SetClusters(map[int][]int{
 		0: {0},
 		1: {1},
 		2: {2},
 		3: {3},
 		4: {4},
-	}
+	})
 
 	inst.Distances = [][]int{
 		{0, 8, 9, 10, 11},
@@ -25,7 +26,7 @@ func TestSolution_InsertCluster(t *testing.T) {
 		{0, 0, 0, 0, 0},
 	}
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	// hand-defined cluster sequence: 0 -> 1 -> 2 -> 3 -> 4 -> 0
 
@@ -72,19 +73,222 @@ func TestSolution_InsertCluster(t *testing.T) {
 	assert.Equal(t, solution.Distance, expectedDistance)
 }
 
+func TestSolution_InsertClusterDelta(t *testing.T) {
+	for _, asymmetry := range []int{0, 50} {
+		testInsertClusterDelta(t, asymmetry)
+	}
+}
+
+func testInsertClusterDelta(t *testing.T, asymmetry int) {
+	inst, err := NewInstance(30, 8, pkg.NewRand(1), Asymmetric(asymmetry))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	// the predicted change has to match the actual one for every move
+
+	for cluster := 0; cluster < inst.ClusterCount; cluster++ {
+		for position := 0; position < inst.ClusterCount; position++ {
+			if position == cluster {
+				continue
+			}
+			s := solution.DeepCopy()
+			delta := s.InsertClusterDelta(cluster, position)
+			before := s.Distance
+			s.InsertCluster(cluster, position)
+			assert.Equal(t, before+delta, s.Distance)
+
+			s.CalculateDistance()
+			assert.Equal(t, before+delta, s.Distance)
+		}
+	}
+}
+
+func TestSolution_ReverseSegment(t *testing.T) {
+	inst, err := NewInstance(30, 8, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	// reverse the segment of 3 clusters following cluster 0
+
+	first := solution.NextCluster[0]
+	middle := solution.NextCluster[first]
+	last := solution.NextCluster[middle]
+	after := solution.NextCluster[last]
+
+	solution.ReverseSegment(first, last)
+
+	assert.Equal(t, last, solution.NextCluster[0])
+	assert.Equal(t, middle, solution.NextCluster[last])
+	assert.Equal(t, first, solution.NextCluster[middle])
+	assert.Equal(t, after, solution.NextCluster[first])
+	assert.True(t, solution.IsFeasible())
+
+	distance := solution.Distance
+	solution.CalculateDistance()
+	assert.Equal(t, solution.Distance, distance)
+
+	// reversing the whole tour keeps the distance of a symmetric instance
+
+	solution.ReverseSegment(solution.NextCluster[0], 0)
+	assert.Equal(t, first, solution.NextCluster[after])
+	assert.Equal(t, distance, solution.Distance)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestSolution_ReverseSegmentAsymmetric(t *testing.T) {
+	inst, err := NewInstance(30, 8, pkg.NewRand(1), Asymmetric(50))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	// reversed edges change their lengths, which has to be reflected in the distance
+
+	first := solution.NextCluster[0]
+	last := solution.NextCluster[solution.NextCluster[solution.NextCluster[first]]]
+	solution.ReverseSegment(first, last)
+	distance := solution.Distance
+	solution.CalculateDistance()
+	assert.Equal(t, solution.Distance, distance)
+
+	solution.ReverseSegment(solution.NextCluster[0], 0)
+	distance = solution.Distance
+	solution.CalculateDistance()
+	assert.Equal(t, solution.Distance, distance)
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestSolution_SwapClusters(t *testing.T) {
+	inst, err := NewInstance(30, 8, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+	order := clusterOrder(solution)
+
+	// swap the 2nd and the 5th cluster of the tour
+
+	solution.SwapClusters(order[1], order[4])
+	assert.Equal(t, []int{order[0], order[4], order[2], order[3], order[1], order[5], order[6], order[7]}, clusterOrder(solution))
+	assert.True(t, solution.IsFeasible())
+
+	// adjacent clusters, given in either order
+
+	solution.SwapClusters(order[2], order[3])
+	assert.Equal(t, []int{order[0], order[4], order[3], order[2], order[1], order[5], order[6], order[7]}, clusterOrder(solution))
+	solution.SwapClusters(order[5], order[1])
+	assert.Equal(t, []int{order[0], order[4], order[3], order[2], order[5], order[1], order[6], order[7]}, clusterOrder(solution))
+	assert.True(t, solution.IsFeasible())
+
+	distance := solution.Distance
+	solution.CalculateDistance()
+	assert.Equal(t, solution.Distance, distance)
+}
+
+// returns the clusters in the visiting order, starting from cluster 0
+func clusterOrder(solution *Solution) []int {
+	order := []int{0}
+	for cluster := solution.NextCluster[0]; cluster != 0; cluster = solution.NextCluster[cluster] {
+		order = append(order, cluster)
+	}
+	return order
+}
+
+func TestSolution_SwapClustersDelta(t *testing.T) {
+	for _, clusterCount := range []int{3, 4, 8} {
+		inst, err := NewInstance(30, clusterCount, pkg.NewRand(1), Asymmetric(50))
+		assert.True(t, err == nil)
+
+		solution := GenerateSolution(inst, pkg.NewRand(1))
+
+		// every pair, adjacent or not, in both orders
+
+		for a := 0; a < clusterCount; a++ {
+			for b := 0; b < clusterCount; b++ {
+				swapped := solution.DeepCopy()
+				delta := swapped.SwapClustersDelta(a, b)
+				swapped.SwapClusters(a, b)
+				assert.True(t, swapped.IsFeasible())
+				assert.Equal(t, solution.Distance+delta, swapped.Distance)
+
+				swapped.CalculateDistance()
+				assert.Equal(t, solution.Distance+delta, swapped.Distance)
+			}
+		}
+	}
+}
+
+func TestSolution_MoveSegment(t *testing.T) {
+	inst, err := NewInstance(30, 8, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+	tour := solution.Tour()
+
+	// move the segment of the 2nd and 3rd cluster of the tour after the 6th one
+
+	first := solution.NextCluster[0]
+	last := solution.NextCluster[first]
+	target := solution.NextCluster[solution.NextCluster[solution.NextCluster[last]]]
+
+	solution.MoveSegment(first, last, target, false)
+	assert.Equal(t, []int{tour[0], tour[3], tour[4], tour[5], tour[1], tour[2], tour[6], tour[7]}, solution.Tour())
+	assert.True(t, solution.IsFeasible())
+
+	distance := solution.Distance
+	solution.CalculateDistance()
+	assert.Equal(t, solution.Distance, distance)
+
+	// moving it after the preceding cluster only reverses it in place
+
+	solution.MoveSegment(first, last, target, true)
+	assert.Equal(t, []int{tour[0], tour[3], tour[4], tour[5], tour[2], tour[1], tour[6], tour[7]}, solution.Tour())
+}
+
+func TestSolution_MoveSegmentAsymmetric(t *testing.T) {
+	inst, err := NewInstance(30, 8, pkg.NewRand(1), Asymmetric(50))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	// every position and orientation of the segment keeps the distance consistent
+
+	first := solution.NextCluster[0]
+	last := solution.NextCluster[solution.NextCluster[first]]
+	for _, reversed := range []bool{false, true} {
+		for target := 0; target < inst.ClusterCount; target++ {
+			if target == first || target == last || target == solution.NextCluster[first] {
+				continue
+			}
+			solution.MoveSegment(first, last, target, reversed)
+			assert.True(t, solution.IsFeasible())
+
+			distance := solution.Distance
+			solution.CalculateDistance()
+			assert.Equal(t, solution.Distance, distance)
+
+			if reversed {
+				first, last = last, first
+			}
+		}
+	}
+}
+
 func TestSolution_SwapVertexInCluster_ClusterSizeOne(t *testing.T) {
-	inst, err := NewInstance(10, 3)
+	inst, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
 	// only a single vertex in cluster 1
 
-	inst.Clusters[0] = []int{0}
+	clusters := inst.Clusters()
+	clusters[0] = []int{0}
+	inst.SetClusters(clusters)
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	vertex := solution.Vertices[0]
 
-	solution.SwapVertexInCluster(0)
+	solution.SwapVertexInCluster(0, pkg.NewRand(1))
 
 	// the vertex should have not changed
 
@@ -92,25 +296,83 @@ func TestSolution_SwapVertexInCluster_ClusterSizeOne(t *testing.T) {
 }
 
 func TestSolution_SwapVertexInCluster(t *testing.T) {
-	inst, err := NewInstance(10, 3)
+	inst, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	inst.Clusters[0] = []int{0, 3}
+	clusters := inst.Clusters()
+	clusters[0] = []int{0, 3}
+	inst.SetClusters(clusters)
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	initialVertex := solution.Vertices[0]
 
-	solution.SwapVertexInCluster(0)
+	solution.SwapVertexInCluster(0, pkg.NewRand(1))
 
 	assert.NotEqual(t, solution.Vertices[0], initialVertex)
 }
 
+func TestSolution_SwapVertexInClusterUpdatesDistance(t *testing.T) {
+	inst, err := NewInstance(20, 4, pkg.NewRand(1), Asymmetric(50))
+	assert.True(t, err == nil)
+
+	clusters := inst.Clusters()
+	clusters[0] = []int{0, 4, 8}
+	inst.SetClusters(clusters)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+	before := solution.Distance
+
+	gain := solution.SwapVertexInCluster(0, pkg.NewRand(1))
+	after := solution.Distance
+
+	assert.Equal(t, before-after, gain)
+
+	// incrementally updated distance should match the full recalculation
+
+	solution.CalculateDistance()
+	assert.Equal(t, after, solution.Distance)
+}
+
+func TestSolution_SetVertex(t *testing.T) {
+	inst, err := NewInstance(5, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.Distances = [][]int{
+		{0, 5, 8, 12, 10},
+		{0, 0, 10, 4, 13},
+		{0, 0, 0, 12, 15},
+		{0, 0, 0, 0, 9},
+		{0, 0, 0, 0, 0},
+	}
+
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1, 3},
+		2: {2, 4},
+	})
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+	solution.PrevCluster = []int{2, 0, 1}
+	solution.NextCluster = []int{1, 2, 0}
+	solution.Vertices = []int{0, 1, 4}
+	solution.CalculateDistance()
+
+	// 0 -> 1 -> 4 -> 0 = 5 + 13 + 10 = 28
+	// 0 -> 3 -> 4 -> 0 = 12 + 9 + 10 = 31
+
+	gain := solution.SetVertex(1, 3)
+
+	assert.Equal(t, -3, gain)
+	assert.Equal(t, 31, solution.Distance)
+	assert.Equal(t, 3, solution.Vertices[1])
+}
+
 func TestSolution_UpdateDistance(t *testing.T) {
-	inst, err := NewInstance(10, 3)
+	inst, err := NewInstance(10, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	distance := solution.Distance
 	incrementBy := 5
@@ -121,7 +383,7 @@ func TestSolution_UpdateDistance(t *testing.T) {
 }
 
 func TestSolution_CalculateDistance(t *testing.T) {
-	inst, err := NewInstance(5, 3)
+	inst, err := NewInstance(5, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
 	inst.Distances = [][]int{
@@ -132,13 +394,13 @@ func TestSolution_CalculateDistance(t *testing.T) {
 		{0, 0, 0, 0, 0},
 	}
 
-	inst.Clusters = map[int][]int{
+	inst.SetClusters(map[int][]int{
 		0: {0},
 		1: {1, 3},
 		2: {2, 4},
-	}
+	})
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 	solution.Vertices = []int{0, 1, 4}
 
 	// recalculate the distance
@@ -155,24 +417,24 @@ func TestSolution_CalculateDistance(t *testing.T) {
 }
 
 func TestSolution_IsFeasible_IncorrectVertex(t *testing.T) {
-	inst, err := NewInstance(5, 3)
+	inst, err := NewInstance(5, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	// assign vertex from a different cluster
 	// e.g. first vertex from cluster 1 as a vertex in cluster 0
 
-	solution.Vertices[0] = inst.Clusters[1][0]
+	solution.Vertices[0] = inst.Cluster(1)[0]
 
 	assert.False(t, solution.IsFeasible())
 }
 
 func TestSolution_IsFeasible_PreviousNextDoNotCorrespond(t *testing.T) {
-	inst, err := NewInstance(5, 3)
+	inst, err := NewInstance(5, 3, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	// assign vertex from a different cluster
 	// e.g. first vertex from cluster 1 as a vertex in cluster 0
@@ -187,25 +449,98 @@ func TestSolution_GenerateSolution(t *testing.T) {
 
 	// checks if generated solution is feasible
 
-	inst, err := NewInstance(5, 3)
+	inst, err := NewInstance(5, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	assert.True(t, solution.IsFeasible())
+}
+
+func TestSolution_GenerateSolution_SingleCluster(t *testing.T) {
+	inst, err := NewInstance(4, 1, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	solution := GenerateSolution(*inst)
+	// the only cluster forms the whole cycle, a loop at one of its vertices
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 
 	assert.True(t, solution.IsFeasible())
+	assert.Equal(t, []int{0}, solution.NextCluster)
+	assert.Equal(t, []int{0}, solution.PrevCluster)
+	assert.Equal(t, 0, solution.Distance)
 }
 
 func TestSolution_DeepCopy(t *testing.T) {
-	inst, err := NewInstance(110, 5)
+	inst, err := NewInstance(110, 5, pkg.NewRand(1))
 	assert.True(t, err == nil)
 
-	solution := GenerateSolution(*inst)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
 	deepCopy := solution.DeepCopy()
 
 	assert.Equal(t, *solution, *deepCopy)
-	assert.False(t, &solution.Vertices == &deepCopy.Vertices)
-	assert.False(t, &solution.PrevCluster == &deepCopy.PrevCluster)
-	assert.False(t, &solution.NextCluster == &deepCopy.NextCluster)
-	assert.False(t, &solution.Distance == &deepCopy.Distance)
-	assert.False(t, &solution.Instance == &deepCopy.Instance)
+
+	// the instance is shared, while the tour belongs to the copy only
+
+	assert.True(t, solution.Instance == deepCopy.Instance)
+
+	deepCopy.Vertices[0] = -1
+	deepCopy.PrevCluster[0] = -1
+	deepCopy.NextCluster[0] = -1
+	assert.NotEqual(t, -1, solution.Vertices[0])
+	assert.NotEqual(t, -1, solution.PrevCluster[0])
+	assert.NotEqual(t, -1, solution.NextCluster[0])
+}
+
+// copying a solution costs O(M), compared to O(N^2) of copying its instance,
+// see BenchmarkInstance_DeepCopy
+
+func BenchmarkSolution_DeepCopy(b *testing.B) {
+	inst, err := NewInstance(1000, 200, pkg.NewRand(1))
+	assert.True(b, err == nil)
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		solution.DeepCopy()
+	}
+}
+
+func BenchmarkInstance_DeepCopy(b *testing.B) {
+	inst, err := NewInstance(1000, 200, pkg.NewRand(1))
+	assert.True(b, err == nil)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		inst.DeepCopy()
+	}
+}
+
+func TestSolution_SolutionFromTour(t *testing.T) {
+	inst, err := NewInstance(30, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := GenerateSolution(inst, pkg.NewRand(1))
+
+	rebuilt, err := SolutionFromTour(inst, solution.Tour())
+	assert.True(t, err == nil)
+
+	assert.Equal(t, solution.Distance, rebuilt.Distance)
+	assert.Equal(t, solution.Vertices, rebuilt.Vertices)
+	assert.Equal(t, solution.NextCluster, rebuilt.NextCluster)
+	assert.Equal(t, solution.PrevCluster, rebuilt.PrevCluster)
+}
+
+func TestSolution_SolutionFromTour_ClusterVisitedTwice(t *testing.T) {
+	inst, err := NewInstance(5, 3, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	inst.SetClusters(map[int][]int{
+		0: {0},
+		1: {1, 3},
+		2: {2, 4},
+	})
+
+	_, err = SolutionFromTour(inst, []int{0, 1, 3})
+	assert.EqualValues(t, "cluster 1 is visited more than once", err.Error())
 }
@@ -0,0 +1,69 @@
+package gtsp
+
+// TriangleReport summarises the violations of the triangle inequality d(i, k) <= d(i, j) + d(j, k)
+// over every triple of distinct nodes i, j and k
+type TriangleReport struct {
+	Violations int
+	// the largest amount by which a distance d(i, k) exceeds the path through j,
+	// along with the nodes of the worst violation. 0 if there are no violations
+	Worst     int
+	WorstFrom int
+	WorstVia  int
+	WorstTo   int
+}
+
+func (r TriangleReport) Satisfied() bool {
+	return r.Violations == 0
+}
+
+// CheckTriangleInequality goes through every triple of nodes, which takes O(N^3) time
+func (inst *Instance) CheckTriangleInequality() TriangleReport {
+	d := inst.DistanceMatrix()
+
+	var report TriangleReport
+	for i := 0; i < inst.NodeCount; i++ {
+		for j := 0; j < inst.NodeCount; j++ {
+			if j == i {
+				continue
+			}
+			for k := 0; k < inst.NodeCount; k++ {
+				if k == i || k == j {
+					continue
+				}
+				excess := d[i][k] - d[i][j] - d[j][k]
+				if excess <= 0 {
+					continue
+				}
+				report.Violations++
+				if excess > report.Worst {
+					report.Worst = excess
+					report.WorstFrom, report.WorstVia, report.WorstTo = i, j, k
+				}
+			}
+		}
+	}
+	return report
+}
+
+// MetricClosure replaces every distance with the length of the shortest path between
+// the nodes, using the Floyd–Warshall algorithm. the resulting matrix satisfies the
+// triangle inequality, and the instance is marked so
+func (inst *Instance) MetricClosure() {
+	d := inst.DistanceMatrix()
+
+	for j := 0; j < inst.NodeCount; j++ {
+		for i := 0; i < inst.NodeCount; i++ {
+			if i == j {
+				continue
+			}
+			for k := 0; k < inst.NodeCount; k++ {
+				if via := d[i][j] + d[j][k]; via < d[i][k] {
+					d[i][k] = via
+				}
+			}
+		}
+	}
+
+	inst.Distances = d
+	inst.Triangle = true
+}
@@ -0,0 +1,102 @@
+package gtsp
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInstance_CheckTriangleInequality(t *testing.T) {
+	instance, err := NewInstance(4, 2, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	// 0 -> 2 is longer than 0 -> 1 -> 2 by 3, and 0 -> 3 than 0 -> 1 -> 3 by 1.
+	// only the upper triangle is read for a symmetric instance
+
+	instance.Distances = [][]int{
+		{0, 1, 5, 3},
+		{0, 0, 1, 1},
+		{0, 0, 0, 1},
+		{0, 0, 0, 0},
+	}
+
+	report := instance.CheckTriangleInequality()
+
+	// 0 -> 2 is also longer than 0 -> 3 -> 2 by 1, and every violation counts
+	// in both directions
+
+	assert.False(t, report.Satisfied())
+	assert.Equal(t, 6, report.Violations)
+	assert.Equal(t, 3, report.Worst)
+	assert.Equal(t, 0, report.WorstFrom)
+	assert.Equal(t, 1, report.WorstVia)
+	assert.Equal(t, 2, report.WorstTo)
+}
+
+func TestInstance_CheckTriangleInequalityAsymmetric(t *testing.T) {
+	instance, err := NewInstance(3, 1, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	instance.Symmetric = false
+	instance.Distances = [][]int{
+		{0, 1, 4},
+		{1, 0, 1},
+		{1, 1, 0},
+	}
+
+	// only 0 -> 2 violates the inequality, 2 -> 0 doesn't
+
+	report := instance.CheckTriangleInequality()
+	assert.Equal(t, 1, report.Violations)
+	assert.Equal(t, 2, report.Worst)
+}
+
+func TestInstance_MetricClosure(t *testing.T) {
+	instance, err := NewInstance(4, 2, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	instance.Distances = [][]int{
+		{0, 1, 5, 3},
+		{0, 0, 1, 1},
+		{0, 0, 0, 1},
+		{0, 0, 0, 0},
+	}
+
+	instance.MetricClosure()
+
+	assert.Equal(t, [][]int{
+		{0, 1, 2, 2},
+		{1, 0, 1, 1},
+		{2, 1, 0, 1},
+		{2, 1, 1, 0},
+	}, instance.Distances)
+	assert.True(t, instance.Triangle)
+	assert.True(t, instance.CheckTriangleInequality().Satisfied())
+}
+
+func TestNewInstance_TriangleFlag(t *testing.T) {
+
+	// Manhattan distances between integer coordinates are a metric
+
+	manhattan, err := NewInstance(40, 8, pkg.NewRand(1))
+	assert.True(t, err == nil)
+	assert.True(t, manhattan.Triangle)
+	assert.True(t, manhattan.CheckTriangleInequality().Satisfied())
+
+	asymmetric, err := NewInstance(40, 8, pkg.NewRand(1), Asymmetric(50))
+	assert.True(t, err == nil)
+	assert.False(t, asymmetric.Triangle)
+
+	closure, err := NewInstance(40, 8, pkg.NewRand(1), Asymmetric(50), WithMetricClosure())
+	assert.True(t, err == nil)
+	assert.True(t, closure.Triangle)
+	assert.True(t, closure.CheckTriangleInequality().Satisfied())
+
+	// closure never makes a distance longer
+
+	for i := 0; i < 40; i++ {
+		for j := 0; j < 40; j++ {
+			assert.True(t, closure.GetDistance(i, j) <= asymmetric.GetDistance(i, j))
+		}
+	}
+}
@@ -4,73 +4,105 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/olegnalivajev/cmcs/pkg/gtsp"
-	"os"
+	"io"
 	"strconv"
 )
 
-func ExportInstance(instance gtsp.Instance, location string) {
+func ExportInstance(instance gtsp.Instance, location string, options ...WriteOption) error {
+	return writeFile(location+"/"+instance.GetInstanceName()+".txt", func(w io.Writer) error {
+		return WriteInstance(w, &instance, options...)
+	})
+}
 
-	// exports the instance in a format described here:
-	// http://www.cs.nott.ac.uk/~pszdk/gtsp.html
-	// see `Text Format (Instance)` section
+// WriteOption changes how WriteInstance writes the instance
+type WriteOption func(config *writeConfig)
+
+type writeConfig struct {
+	coordinates   bool
+	omitDistances bool
+}
 
-	f, err := os.Create(location+"/"+instance.GetInstanceName()+".txt")
-	check(err)
+// WithCoordinates adds the coordinates of the nodes after the clusters. the section
+// isn't a part of the original format, so only readers aware of it can read the file
+func WithCoordinates() WriteOption {
+	return func(config *writeConfig) {
+		config.coordinates = true
+	}
+}
 
-	// close file on exit and check for its returned error
+// OmitDistances writes the coordinates and leaves out the distance matrix when they
+// define every distance. the file is much smaller, but only readers aware of the
+// coordinates section can read it
+func OmitDistances() WriteOption {
+	return func(config *writeConfig) {
+		config.coordinates = true
+		config.omitDistances = true
+	}
+}
 
-	defer func() {
-		if err := f.Close(); err != nil {
-			panic(err)
-		}
-	}()
+func WriteInstance(writer io.Writer, instance *gtsp.Instance, options ...WriteOption) error {
 
-	// make a write buffer
+	// writes the instance in a format described here:
+	// http://www.cs.nott.ac.uk/~pszdk/gtsp.html
+	// see `Text Format (Instance)` section
+
+	// make a write buffer. bufio.Writer remembers the first error it ran into,
+	// so it's enough to check the result of Flush
 
-	w := bufio.NewWriter(f)
+	w := bufio.NewWriter(writer)
+
+	config := writeConfig{}
+	for _, option := range options {
+		option(&config)
+	}
 
 	// headers
 
-	_, err = w.WriteString("N: " + strconv.Itoa(instance.NodeCount) + "\n")
-	check(err)
-	_, err = w.WriteString("M: " + strconv.Itoa(instance.ClusterCount) + "\n")
-	check(err)
-	_, err = w.WriteString("Symmetric: " + strconv.FormatBool(instance.Symmetric) +"\n")
-	check(err)
-	_, err = w.WriteString("Triangle: " + strconv.FormatBool(instance.Triangle) + "\n")
-	check(err)
+	fmt.Fprintf(w, "N: %d\n", instance.NodeCount)
+	fmt.Fprintf(w, "M: %d\n", instance.ClusterCount)
+	fmt.Fprintf(w, "Symmetric: %t\n", instance.Symmetric)
+	fmt.Fprintf(w, "Triangle: %t\n", instance.Triangle)
 
-	// clusters
+	// clusters, in the order of their indices, as the position
+	// of the line is what identifies the cluster
 
-	for _, nodes := range instance.Clusters {
-		_, err = w.WriteString(fmt.Sprintf("%d ", len(nodes)))
-		check(err)
+	for i := 0; i < instance.ClusterCount; i++ {
+		nodes := instance.Cluster(i)
+		fmt.Fprintf(w, "%d", len(nodes))
 		for _, node := range nodes {
-			_, err = w.WriteString(fmt.Sprintf("%d ", node))
-			check(err)
+			fmt.Fprintf(w, " %d", node)
 		}
-		_, err := w.WriteString("\n")
-		check(err)
+		fmt.Fprintln(w)
 	}
 
-	// distance matrix
+	// coordinates of the nodes, on request, as they aren't a part of the original format
 
-	for _, rows := range instance.Distances {
-		for _, dist := range rows {
-			_, err := w.WriteString(fmt.Sprintf("%d ", dist))
-			check(err)
+	if config.coordinates && instance.HasCoordinates() {
+		fmt.Fprintf(w, "Coordinates: %s\n", instance.Metric)
+		for _, c := range instance.Coordinates {
+			fmt.Fprintf(w, "%s %s\n", formatFloat(c.X), formatFloat(c.Y))
 		}
-		_, err := w.WriteString("\n")
-		check(err)
 	}
 
-	err = w.Flush()
-	check(err)
-}
+	// distance matrix, which can only be omitted on request, and only if it
+	// can be calculated from the coordinates
+
+	if !config.omitDistances || !instance.CoordinatesDefineDistances() {
+		for _, rows := range instance.DistanceMatrix() {
+			for j, dist := range rows {
+				if j > 0 {
+					fmt.Fprint(w, " ")
+				}
+				fmt.Fprintf(w, "%d", dist)
+			}
+			fmt.Fprintln(w)
+		}
+	}
 
+	return w.Flush()
+}
 
-func check(e error) {
-	if e != nil {
-		panic(e)
-	}
+// formats the number without an exponent and with as few digits as needed to read it back
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
 }
@@ -0,0 +1,42 @@
+package io
+
+import (
+	"io"
+	"os"
+)
+
+// opens the file at the location and reads it with `read`
+func readFile(location string, read func(r io.Reader) error) (err error) {
+	f, err := os.Open(location)
+	if err != nil {
+		return err
+	}
+
+	// close file on exit and check for its returned error
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return read(f)
+}
+
+// creates the file at the location, or truncates an existing one, and writes it with `write`
+func writeFile(location string, write func(w io.Writer) error) (err error) {
+	f, err := os.Create(location)
+	if err != nil {
+		return err
+	}
+
+	// close file on exit and check for its returned error
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return write(f)
+}
@@ -2,90 +2,290 @@ package io
 
 import (
 	"bufio"
+	"fmt"
 	"github.com/olegnalivajev/cmcs/pkg/gtsp"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 )
 
-func ImportInstance(location string) (*gtsp.Instance, error) {
+func ImportInstance(location string) (inst *gtsp.Instance, err error) {
+	err = readFile(location, func(r io.Reader) error {
+		inst, err = ReadInstance(r)
+		return err
+	})
+	return inst, err
+}
+
+func ReadInstance(reader io.Reader) (*gtsp.Instance, error) {
 
-	// imports the instance in a format described here:
+	// reads the instance in a format described here:
 	// http://www.cs.nott.ac.uk/~pszdk/gtsp.html
 	// see `Text Format (Instance)` section
 
-	f, err := os.Open(location)
-	check(err)
+	r := newLineReader(reader)
 
-	// close file on exit and check for its returned error
+	// extract headers
 
-	defer func() {
-		if err := f.Close(); err != nil {
-			panic(err)
-		}
-	}()
+	nodeCount, err := r.readIntHeader("N")
+	if err != nil {
+		return nil, err
+	}
+	if nodeCount <= 0 {
+		return nil, r.errorf("`N` expected to be positive, got %d", nodeCount)
+	}
 
-	scanner := bufio.NewScanner(f)
+	clusterCount, err := r.readIntHeader("M")
+	if err != nil {
+		return nil, err
+	}
+	if clusterCount <= 0 || clusterCount > nodeCount {
+		return nil, r.errorf("`M` expected to be between 1 and %d, got %d", nodeCount, clusterCount)
+	}
 
-	// extract nodeCount
+	symmetric, err := r.readBoolHeader("Symmetric")
+	if err != nil {
+		return nil, err
+	}
 
-	scanner.Scan()
-	nodeCountString := scanner.Text()
-	nodeCountSlice := strings.Split(nodeCountString, ":")
-	nodeCount, _ := strconv.Atoi(strings.TrimSpace(nodeCountSlice[len(nodeCountSlice)-1]))
+	triangle, err := r.readBoolHeader("Triangle")
+	if err != nil {
+		return nil, err
+	}
 
-	// extract clusterCount
+	// extract clusters. each line starts with the number of vertices in the cluster,
+	// followed by the vertices themselves. every vertex has to belong to exactly one cluster
 
-	scanner.Scan()
-	clusterCountString := scanner.Text()
-	clusterCountSlice := strings.Split(clusterCountString, ":")
-	clusterCount, _ := strconv.Atoi(strings.TrimSpace(clusterCountSlice[len(clusterCountSlice)-1]))
+	clusters := make(map[int][]int)
+	seen := make([]bool, nodeCount)
+	total := 0
 
-	// extract Symmetric
+	for i := 0; i < clusterCount; i++ {
+		values, err := r.readInts()
+		if err != nil {
+			return nil, err
+		}
 
-	scanner.Scan()
-	symmetricString := scanner.Text()
-	symmetricSlice := strings.Split(symmetricString, ":")
-	symmetric, _ := strconv.ParseBool(strings.TrimSpace(symmetricSlice[len(symmetricSlice)-1]))
+		size := values[0]
+		if size <= 0 {
+			return nil, r.errorf("cluster %d expected to have at least one vertex, got %d", i, size)
+		}
+		if len(values)-1 != size {
+			return nil, r.errorf("cluster %d declares %d vertices, but lists %d", i, size, len(values)-1)
+		}
 
-	// extract Triangle
+		for _, v := range values[1:] {
+			if v < 0 || v >= nodeCount {
+				return nil, r.errorf("vertex %d is out of range [0, %d)", v, nodeCount)
+			}
+			if seen[v] {
+				return nil, r.errorf("vertex %d belongs to more than one cluster", v)
+			}
+			seen[v] = true
+		}
 
-	scanner.Scan()
-	triangleString := scanner.Text()
-	triangleSlice := strings.Split(triangleString, ":")
-	triangle, _ := strconv.ParseBool(strings.TrimSpace(triangleSlice[len(triangleSlice)-1]))
+		clusters[i] = values[1:]
+		total += size
+	}
 
-	// extract clusters
+	if total != nodeCount {
+		return nil, r.errorf("clusters contain %d vertices in total, expected %d", total, nodeCount)
+	}
 
-	clusters := make(map[int][]int)
+	inst := &gtsp.Instance{
+		NodeCount:    nodeCount,
+		ClusterCount: clusterCount,
+		Symmetric:    symmetric,
+		Triangle:     triangle,
+	}
+	inst.SetClusters(clusters)
 
-	for i := 0; i < clusterCount; i++ {
-		scanner.Scan()
-		clustersIRowString := scanner.Text()
-		clustersIRowSlice := strings.Split(clustersIRowString, " ")
+	// the clusters can be followed by an optional `Coordinates: <metric>` section,
+	// listing `x y` of every node, which isn't a part of the original format
+
+	line, ok := r.next()
+	if ok && !isCoordinatesHeader(line) {
+		r.unread(line)
+	} else if ok {
+		inst.Metric, err = gtsp.ParseMetric(strings.TrimSpace(strings.SplitN(line, ":", 2)[1]))
+		if err != nil {
+			return nil, r.errorf("%v", err)
+		}
+
+		inst.Coordinates = make([]gtsp.NodeCoord, nodeCount)
+		for i := range inst.Coordinates {
+			values, err := r.readFloats()
+			if err != nil {
+				return nil, err
+			}
+			if len(values) != 2 {
+				return nil, r.errorf("coordinate of node %d has %d values, expected 2", i, len(values))
+			}
+			inst.Coordinates[i] = gtsp.NodeCoord{X: values[0], Y: values[1]}
+		}
 
-		// make an int slice
+		// without a distance matrix the distances are calculated from the coordinates
 
-		numberOfNodesInClusterI, _ := strconv.Atoi(clustersIRowSlice[0])
-		clusterI := make([]int, numberOfNodesInClusterI)
-		for
-		clusterI = append(clusterI, )
+		line, ok := r.next()
+		if !ok {
+			if err := r.scanner.Err(); err != nil {
+				return nil, r.errorf("%v", err)
+			}
+			return inst, nil
+		}
+		r.unread(line)
 	}
 
 	// extract distances
 
-	// initialise Distances slice
-	w := make([][]int, nodeCount)
-	for i := range w {
-		w[i] = make([]int, nodeCount)
+	inst.Distances = make([][]int, nodeCount)
+	for i := range inst.Distances {
+		row, err := r.readInts()
+		if err != nil {
+			return nil, err
+		}
+		if len(row) != nodeCount {
+			return nil, r.errorf("distance matrix row %d has %d values, expected %d", i, len(row), nodeCount)
+		}
+		inst.Distances[i] = row
 	}
 
-	inst := &gtsp.Instance{
-		NodeCount: nodeCount,
-		ClusterCount: clusterCount,
-		Symmetric: symmetric,
-		Triangle: triangle,
+	// anything after the matrix means the dimensions are wrong
+
+	if _, ok := r.next(); ok {
+		return nil, r.errorf("distance matrix has more than %d rows", nodeCount)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, r.errorf("%v", err)
 	}
 
 	return inst, nil
 }
+
+func isCoordinatesHeader(line string) bool {
+	parts := strings.SplitN(line, ":", 2)
+	return len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "Coordinates")
+}
+
+// reads the input line by line, skipping empty lines and
+// keeping track of the line number for error messages
+type lineReader struct {
+	scanner *bufio.Scanner
+	line    int
+	pending *string
+}
+
+func newLineReader(reader io.Reader) *lineReader {
+	scanner := bufio.NewScanner(reader)
+
+	// a row of the distance matrix can easily exceed the default
+	// token limit for instances with thousands of nodes
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<30)
+
+	return &lineReader{scanner: scanner}
+}
+
+func (r *lineReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+// returns the next non-empty line, or false once the input is exhausted
+func (r *lineReader) next() (string, bool) {
+	if r.pending != nil {
+		line := *r.pending
+		r.pending = nil
+		return line, true
+	}
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line != "" {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// returns the line to the reader, so that the following call to next returns it again
+func (r *lineReader) unread(line string) {
+	r.pending = &line
+}
+
+func (r *lineReader) readLine() (string, error) {
+	if line, ok := r.next(); ok {
+		return line, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", r.errorf("%v", err)
+	}
+	return "", fmt.Errorf("line %d: unexpected end of file", r.line+1)
+}
+
+func (r *lineReader) readHeader(name string) (string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return "", err
+	}
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 || !strings.EqualFold(strings.TrimSpace(parts[0]), name) {
+		return "", r.errorf("expected `%s: <value>` header, got %q", name, line)
+	}
+	return strings.TrimSpace(parts[1]), nil
+}
+
+func (r *lineReader) readIntHeader(name string) (int, error) {
+	value, err := r.readHeader(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, r.errorf("`%s` expected to be an integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+func (r *lineReader) readBoolHeader(name string) (bool, error) {
+	value, err := r.readHeader(name)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, r.errorf("`%s` expected to be true or false, got %q", name, value)
+	}
+	return b, nil
+}
+
+func (r *lineReader) readInts() ([]int, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(line)
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		values[i], err = strconv.Atoi(field)
+		if err != nil {
+			return nil, r.errorf("expected an integer, got %q", field)
+		}
+	}
+	return values, nil
+}
+
+func (r *lineReader) readFloats() ([]float64, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(line)
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		values[i], err = strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, r.errorf("expected a number, got %q", field)
+		}
+	}
+	return values, nil
+}
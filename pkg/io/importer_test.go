@@ -0,0 +1,249 @@
+package io
+
+import (
+	"bytes"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp/gtsptest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+const validInstance = `N: 5
+M: 3
+Symmetric: true
+Triangle: false
+1 0
+2 1 3
+2 2 4
+0 5 8 12 10
+5 0 10 4 13
+8 10 0 12 15
+12 4 12 0 9
+10 13 15 9 0
+`
+
+// writes the content into a temporary file and returns its path
+func writeTempFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "cmcs-instance-*.txt")
+	assert.True(t, err == nil)
+	_, err = f.WriteString(content)
+	assert.True(t, err == nil)
+	assert.True(t, f.Close() == nil)
+	return f.Name()
+}
+
+func TestImportInstance(t *testing.T) {
+	location := writeTempFile(t, validInstance)
+	defer os.Remove(location)
+
+	inst, err := ImportInstance(location)
+	assert.True(t, err == nil)
+
+	assert.Equal(t, 5, inst.NodeCount)
+	assert.Equal(t, 3, inst.ClusterCount)
+	assert.True(t, inst.Symmetric)
+	assert.False(t, inst.Triangle)
+	assert.Equal(t, map[int][]int{0: {0}, 1: {1, 3}, 2: {2, 4}}, inst.Clusters())
+	assert.Equal(t, []int{8, 10, 0, 12, 15}, inst.Distances[2])
+}
+
+func TestReadInstance_Coordinates(t *testing.T) {
+	content := `N: 5
+M: 3
+Symmetric: true
+Triangle: false
+1 0
+2 1 3
+2 2 4
+Coordinates: euclidean
+0 0
+3 4
+0.5 8
+-1 2
+6 8
+`
+	inst, err := ReadInstance(strings.NewReader(content))
+	assert.True(t, err == nil)
+
+	// with no distance matrix, the distances come from the coordinates
+
+	assert.True(t, inst.Distances == nil)
+	assert.Equal(t, gtsp.Euclidean, inst.Metric)
+	assert.Equal(t, gtsp.NodeCoord{X: 0.5, Y: 8}, inst.Coordinates[2])
+	assert.Equal(t, 5, inst.GetDistance(0, 1))
+	assert.Equal(t, 10, inst.GetDistance(4, 0))
+}
+
+func TestReadInstance_CoordinatesAndDistances(t *testing.T) {
+	clusters := validInstance[:strings.Index(validInstance, "0 5 8")]
+	matrix := validInstance[len(clusters):]
+	content := clusters + "Coordinates: manhattan\n0 0\n3 4\n0.5 8\n-1 2\n6 8\n" + matrix
+
+	inst, err := ReadInstance(strings.NewReader(content))
+	assert.True(t, err == nil)
+
+	// the distance matrix takes precedence, the coordinates are kept alongside
+
+	assert.Equal(t, []int{8, 10, 0, 12, 15}, inst.Distances[2])
+	assert.Equal(t, gtsp.NodeCoord{X: -1, Y: 2}, inst.Coordinates[3])
+	assert.False(t, inst.CoordinatesDefineDistances())
+}
+
+func TestReadInstance_Errors(t *testing.T) {
+	cases := map[string]string{
+		"N: x\n":                       "line 1: `N` expected to be an integer, got \"x\"",
+		"N: 5\nK: 3\n":                 "line 2: expected `M: <value>` header, got \"K: 3\"",
+		"N: 5\nM: 6\n":                 "line 2: `M` expected to be between 1 and 5, got 6",
+		"N: 5\nM: 3\nSymmetric: yes\n": "line 3: `Symmetric` expected to be true or false, got \"yes\"",
+		"N: 5\nM: 3\nSymmetric: true\nTriangle: false\n1 0\n3 1 3\n":                                 "line 6: cluster 1 declares 3 vertices, but lists 2",
+		"N: 5\nM: 3\nSymmetric: true\nTriangle: false\n1 0\n2 1 7\n":                                 "line 6: vertex 7 is out of range [0, 5)",
+		"N: 5\nM: 3\nSymmetric: true\nTriangle: false\n1 0\n2 1 0\n":                                 "line 6: vertex 0 belongs to more than one cluster",
+		"N: 5\nM: 3\nSymmetric: true\nTriangle: false\n1 0\n2 1 3\n1 2":                              "line 7: clusters contain 4 vertices in total, expected 5",
+		"N: 5\nM: 3\nSymmetric: true\nTriangle: false\n1 0\n2 1 3\n2 2 4\n0 1 2 3\n":                 "line 8: distance matrix row 0 has 4 values, expected 5",
+		"N: 5\nM: 3\nSymmetric: true\nTriangle: false\n1 0\n2 1 3\n2 2 4\n0 1 2 3 4\n":               "line 9: unexpected end of file",
+		validInstance + "1 2 3 4 5\n":                                                                "line 13: distance matrix has more than 5 rows",
+		"N: 2\nM: 1\nSymmetric: true\nTriangle: false\n2 0 1\nCoordinates: chebyshev\n":              "line 6: unknown metric \"chebyshev\", expected manhattan or euclidean",
+		"N: 2\nM: 1\nSymmetric: true\nTriangle: false\n2 0 1\nCoordinates: manhattan\n0 0\n1\n":      "line 8: coordinate of node 1 has 1 values, expected 2",
+		"N: 2\nM: 1\nSymmetric: true\nTriangle: false\n2 0 1\nCoordinates: manhattan\n0 0\n":         "line 8: unexpected end of file",
+		"N: 2\nM: 1\nSymmetric: true\nTriangle: false\n2 0 1\nCoordinates: manhattan\n0 0\n1 1\n0\n": "line 9: distance matrix row 0 has 1 values, expected 2",
+	}
+
+	for content, expected := range cases {
+		_, err := ReadInstance(strings.NewReader(content))
+		if assert.True(t, err != nil, content) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestImportInstance_FileDoesNotExist(t *testing.T) {
+	_, err := ImportInstance(filepath.Join(os.TempDir(), "cmcs-does-not-exist.txt"))
+	assert.True(t, err != nil)
+}
+
+func TestExportInstance_RoundTrip(t *testing.T) {
+	inst, err := gtsp.NewInstance(30, 7, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	dir, err := ioutil.TempDir("", "cmcs")
+	assert.True(t, err == nil)
+	defer os.RemoveAll(dir)
+
+	assert.True(t, ExportInstance(*inst, dir, WithCoordinates()) == nil)
+
+	imported, err := ImportInstance(filepath.Join(dir, inst.GetInstanceName()+".txt"))
+	assert.True(t, err == nil)
+
+	// the seed isn't a part of the format
+
+	imported.Seed = inst.Seed
+	assert.Equal(t, inst, imported)
+}
+
+func TestWriteInstance_RoundTrip(t *testing.T) {
+	inst, err := gtsp.NewInstance(25, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	var buf bytes.Buffer
+	assert.True(t, WriteInstance(&buf, inst) == nil)
+	assert.False(t, strings.Contains(buf.String(), "Coordinates"))
+
+	imported, err := ReadInstance(&buf)
+	assert.True(t, err == nil)
+
+	// the coordinates are written only on request, the rest of the instance is kept
+
+	assert.False(t, imported.HasCoordinates())
+
+	expected := inst.DeepCopy()
+	expected.Coordinates = nil
+	assert.Equal(t, "", expected.Diff(imported))
+}
+
+func TestWriteInstance_RoundTripCoordinates(t *testing.T) {
+	inst, err := gtsp.NewInstance(25, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	var buf bytes.Buffer
+	assert.True(t, WriteInstance(&buf, inst, WithCoordinates()) == nil)
+
+	imported, err := ReadInstance(&buf)
+	assert.True(t, err == nil)
+
+	// the seed isn't a part of the format
+
+	imported.Seed = inst.Seed
+	assert.Equal(t, inst, imported)
+}
+
+func TestWriteInstance_OmitDistances(t *testing.T) {
+	inst, err := gtsp.NewInstance(25, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	var full, coordinates, omitted bytes.Buffer
+	assert.True(t, WriteInstance(&full, inst) == nil)
+	assert.True(t, WriteInstance(&coordinates, inst, WithCoordinates()) == nil)
+	assert.True(t, WriteInstance(&omitted, inst, OmitDistances()) == nil)
+
+	// the matrix is written by default, one row for each node, and
+	// leaving it out implies writing the coordinates
+
+	assert.Equal(t, 4+6+25, strings.Count(full.String(), "\n"))
+	assert.Equal(t, 4+6+1+25+25, strings.Count(coordinates.String(), "\n"))
+	assert.Equal(t, 4+6+1+25, strings.Count(omitted.String(), "\n"))
+
+	// the distances are calculated from the coordinates once the instance is read
+
+	imported, err := ReadInstance(&omitted)
+	assert.True(t, err == nil)
+
+	assert.True(t, imported.Distances == nil)
+	imported.ComputeDistances()
+	imported.Seed = inst.Seed
+	assert.Equal(t, inst, imported)
+}
+
+func TestWriteInstance_RoundTripAsymmetric(t *testing.T) {
+	inst, err := gtsp.NewInstance(25, 6, pkg.NewRand(1), gtsp.Asymmetric(20))
+	assert.True(t, err == nil)
+
+	var buf bytes.Buffer
+	assert.True(t, WriteInstance(&buf, inst, WithCoordinates()) == nil)
+
+	imported, err := ReadInstance(&buf)
+	assert.True(t, err == nil)
+
+	// the distances no longer follow the coordinates, so both are written
+
+	imported.Seed = inst.Seed
+	assert.Equal(t, inst, imported)
+}
+
+func TestWriteInstance_RoundTripProperty(t *testing.T) {
+	property := func(seed int64) bool {
+		inst := gtsptest.RandomInstance(seed)
+
+		var buf bytes.Buffer
+		if err := WriteInstance(&buf, inst, WithCoordinates()); err != nil {
+			t.Log(err)
+			return false
+		}
+		imported, err := ReadInstance(&buf)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		if diff := inst.Diff(imported); diff != "" {
+			t.Log(diff)
+			return false
+		}
+		return true
+	}
+	assert.True(t, quick.Check(property, nil) == nil)
+}
@@ -0,0 +1,84 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func ImportSolution(location string, instance *gtsp.Instance) (solution *gtsp.Solution, err error) {
+	err = readFile(location, func(r io.Reader) error {
+		solution, err = ReadSolution(r, instance)
+		return err
+	})
+	return solution, err
+}
+
+func ExportSolution(solution *gtsp.Solution, location string) error {
+	return writeFile(location+"/"+solution.Instance.GetInstanceName()+".sol", func(w io.Writer) error {
+		return WriteSolution(w, solution)
+	})
+}
+
+func ReadSolution(reader io.Reader, instance *gtsp.Instance) (*gtsp.Solution, error) {
+
+	// reads the solution in a format described here:
+	// http://www.cs.nott.ac.uk/~pszdk/gtsp.html
+	// the first line is the objective value, followed by the vertices in the
+	// visiting order. the objective value has to match the tour on the instance
+
+	r := newLineReader(reader)
+
+	objective, err := r.readInts()
+	if err != nil {
+		return nil, err
+	}
+	if len(objective) != 1 {
+		return nil, r.errorf("expected a single objective value, got %d values", len(objective))
+	}
+
+	tour := make([]int, 0, instance.ClusterCount)
+	for {
+		line, ok := r.next()
+		if !ok {
+			break
+		}
+		for _, field := range strings.Fields(line) {
+			v, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, r.errorf("expected an integer, got %q", field)
+			}
+			tour = append(tour, v)
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, r.errorf("%v", err)
+	}
+
+	solution, err := gtsp.SolutionFromTour(instance, tour)
+	if err != nil {
+		return nil, err
+	}
+	if solution.Distance != objective[0] {
+		return nil, fmt.Errorf("objective value %d doesn't match the length of the tour %d", objective[0], solution.Distance)
+	}
+	return solution, nil
+}
+
+func WriteSolution(writer io.Writer, solution *gtsp.Solution) error {
+	w := bufio.NewWriter(writer)
+
+	fmt.Fprintf(w, "%d\n", solution.Distance)
+	for i, v := range solution.Tour() {
+		if i > 0 {
+			fmt.Fprint(w, " ")
+		}
+		fmt.Fprintf(w, "%d", v)
+	}
+	fmt.Fprintln(w)
+
+	return w.Flush()
+}
@@ -0,0 +1,85 @@
+package io
+
+import (
+	"bytes"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSolution(t *testing.T) {
+	inst, err := ReadInstance(strings.NewReader(validInstance))
+	assert.True(t, err == nil)
+
+	// 0 -> 4 -> 3 -> 0 = 10 + 9 + 12 = 31
+
+	solution, err := ReadSolution(strings.NewReader("31\n0 4 3\n"), inst)
+	assert.True(t, err == nil)
+
+	assert.Equal(t, 31, solution.Distance)
+	assert.Equal(t, []int{0, 3, 4}, solution.Vertices)
+	assert.Equal(t, []int{2, 0, 1}, solution.NextCluster)
+	assert.Equal(t, []int{1, 2, 0}, solution.PrevCluster)
+}
+
+func TestReadSolution_Errors(t *testing.T) {
+	inst, err := ReadInstance(strings.NewReader(validInstance))
+	assert.True(t, err == nil)
+
+	cases := map[string]string{
+		"":               "line 1: unexpected end of file",
+		"31 32\n0 4 3\n": "line 1: expected a single objective value, got 2 values",
+		"31\n0 x 3\n":    "line 2: expected an integer, got \"x\"",
+		"31\n0 4\n":      "tour has 2 vertices, expected 3",
+		"31\n0 4 2\n":    "cluster 2 is visited more than once",
+		"31\n0 4 9\n":    "vertex 9: no such vertex exists in any cluster",
+		"30\n0 4 3\n":    "objective value 30 doesn't match the length of the tour 31",
+	}
+
+	for content, expected := range cases {
+		_, err := ReadSolution(strings.NewReader(content), inst)
+		if assert.True(t, err != nil, content) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestWriteSolution_RoundTrip(t *testing.T) {
+	inst, err := gtsp.NewInstance(40, 8, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	var buf bytes.Buffer
+	assert.True(t, WriteSolution(&buf, solution) == nil)
+
+	imported, err := ReadSolution(&buf, inst)
+	assert.True(t, err == nil)
+	assert.Equal(t, solution.Distance, imported.Distance)
+	assert.Equal(t, solution.Vertices, imported.Vertices)
+	assert.Equal(t, solution.NextCluster, imported.NextCluster)
+	assert.Equal(t, solution.PrevCluster, imported.PrevCluster)
+}
+
+func TestExportSolution_RoundTrip(t *testing.T) {
+	inst, err := gtsp.NewInstance(40, 8, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	dir, err := ioutil.TempDir("", "cmcs")
+	assert.True(t, err == nil)
+	defer os.RemoveAll(dir)
+
+	assert.True(t, ExportSolution(solution, dir) == nil)
+
+	imported, err := ImportSolution(filepath.Join(dir, inst.GetInstanceName()+".sol"), inst)
+	assert.True(t, err == nil)
+	assert.Equal(t, solution.Distance, imported.Distance)
+	assert.Equal(t, solution.Tour(), imported.Tour())
+}
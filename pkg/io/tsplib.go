@@ -0,0 +1,505 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// header values of a TSPLIB file relevant to GTSP instances
+type tsplibHeader struct {
+	typ          string
+	dimension    int
+	sets         int
+	weightType   string
+	weightFormat string
+}
+
+func ImportTSPLIB(location string) (inst *gtsp.Instance, err error) {
+	err = readFile(location, func(r io.Reader) error {
+		inst, err = ReadTSPLIB(r)
+		return err
+	})
+	return inst, err
+}
+
+func ExportTSPLIB(instance gtsp.Instance, location string) error {
+	return writeFile(location+"/"+instance.GetInstanceName()+".gtsp", func(w io.Writer) error {
+		return WriteTSPLIB(w, &instance)
+	})
+}
+
+func ReadTSPLIB(reader io.Reader) (*gtsp.Instance, error) {
+
+	// reads the instance in TSPLIB format extended with GTSP_SETS header and
+	// GTSP_SET_SECTION, as used by GTSPLIB benchmark instances. distances are
+	// either given explicitly, or computed from coordinates of the nodes.
+	// nodes and sets are numbered from 1 in the file, but from 0 in the instance
+
+	r := &tokenReader{lineReader: newLineReader(reader)}
+	header := tsplibHeader{typ: "GTSP"}
+
+	var coords, display []gtsp.NodeCoord
+	var distances [][]int
+	var clusters map[int][]int
+
+	for {
+		line, ok := r.next()
+		if !ok {
+			if err := r.scanner.Err(); err != nil {
+				return nil, r.errorf("%v", err)
+			}
+			break
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		key := strings.ToUpper(strings.TrimSpace(parts[0]))
+		value := ""
+		if len(parts) == 2 {
+			value = strings.TrimSpace(parts[1])
+		}
+
+		var err error
+		switch key {
+		case "NAME", "COMMENT", "EOF":
+		case "TYPE":
+			header.typ = strings.ToUpper(value)
+			if header.typ != "GTSP" && header.typ != "AGTSP" {
+				return nil, r.errorf("unsupported type %q, expected GTSP or AGTSP", value)
+			}
+		case "DIMENSION":
+			header.dimension, err = r.parsePositive(key, value)
+		case "GTSP_SETS":
+			header.sets, err = r.parsePositive(key, value)
+		case "EDGE_WEIGHT_TYPE":
+			header.weightType = strings.ToUpper(value)
+		case "EDGE_WEIGHT_FORMAT":
+			header.weightFormat = strings.ToUpper(value)
+		case "NODE_COORD_TYPE", "DISPLAY_DATA_TYPE":
+		case "NODE_COORD_SECTION":
+			coords, err = r.readCoordSection(header)
+		case "DISPLAY_DATA_SECTION":
+			display, err = r.readCoordSection(header)
+		case "EDGE_WEIGHT_SECTION":
+			distances, err = r.readEdgeWeightSection(header)
+		case "GTSP_SET_SECTION":
+			clusters, err = r.readSetSection(header)
+		default:
+			return nil, r.errorf("unsupported keyword %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key == "EOF" {
+			break
+		}
+	}
+
+	if clusters == nil {
+		return nil, fmt.Errorf("line %d: GTSP_SET_SECTION is missing", r.line)
+	}
+
+	inst := &gtsp.Instance{
+		NodeCount:    header.dimension,
+		ClusterCount: header.sets,
+		Symmetric:    header.typ == "GTSP",
+		Triangle:     false,
+		Distances:    distances,
+		Coordinates:  coords,
+	}
+	inst.SetClusters(clusters)
+
+	// explicit distances may come with coordinates meant only for display
+
+	if distances != nil {
+		if coords == nil {
+			inst.Coordinates = display
+		}
+		return inst, nil
+	}
+
+	// distances given by coordinates are handled only once the whole file is read,
+	// as the edge weight type header isn't required to precede the coordinates.
+	// metrics supported by the instance itself are calculated on demand, the others
+	// are calculated right away
+
+	if coords == nil {
+		return nil, fmt.Errorf("line %d: neither NODE_COORD_SECTION nor EDGE_WEIGHT_SECTION is present", r.line)
+	}
+	switch header.weightType {
+	case "MAN_2D":
+		inst.Metric = gtsp.Manhattan
+	case "EUC_2D":
+		inst.Metric = gtsp.Euclidean
+	default:
+		var err error
+		inst.Distances, err = calculateTSPLIBDistances(header.weightType, coords)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+func WriteTSPLIB(writer io.Writer, instance *gtsp.Instance) error {
+
+	// if the coordinates define every distance, only the coordinates are written.
+	// otherwise the distance matrix is written explicitly and in full, so that
+	// asymmetric instances are preserved as well, along with coordinates for display
+
+	w := bufio.NewWriter(writer)
+
+	typ := "GTSP"
+	if !instance.Symmetric {
+		typ = "AGTSP"
+	}
+
+	fmt.Fprintf(w, "NAME: %s\n", instance.GetInstanceName())
+	fmt.Fprintf(w, "TYPE: %s\n", typ)
+	fmt.Fprintf(w, "DIMENSION: %d\n", instance.NodeCount)
+	fmt.Fprintf(w, "GTSP_SETS: %d\n", instance.ClusterCount)
+
+	if instance.Symmetric && instance.CoordinatesDefineDistances() {
+		weightType := "MAN_2D"
+		if instance.Metric == gtsp.Euclidean {
+			weightType = "EUC_2D"
+		}
+		fmt.Fprintf(w, "EDGE_WEIGHT_TYPE: %s\n", weightType)
+		fmt.Fprintln(w, "NODE_COORD_SECTION")
+		writeTSPLIBCoordinates(w, instance.Coordinates)
+	} else {
+		fmt.Fprintln(w, "EDGE_WEIGHT_TYPE: EXPLICIT")
+		fmt.Fprintln(w, "EDGE_WEIGHT_FORMAT: FULL_MATRIX")
+		if instance.HasCoordinates() {
+			fmt.Fprintln(w, "DISPLAY_DATA_TYPE: TWOD_DISPLAY")
+		}
+
+		fmt.Fprintln(w, "EDGE_WEIGHT_SECTION")
+		for _, rows := range instance.DistanceMatrix() {
+			for j, dist := range rows {
+				if j > 0 {
+					fmt.Fprint(w, " ")
+				}
+				fmt.Fprintf(w, "%d", dist)
+			}
+			fmt.Fprintln(w)
+		}
+
+		if instance.HasCoordinates() {
+			fmt.Fprintln(w, "DISPLAY_DATA_SECTION")
+			writeTSPLIBCoordinates(w, instance.Coordinates)
+		}
+	}
+
+	fmt.Fprintln(w, "GTSP_SET_SECTION")
+	for i := 0; i < instance.ClusterCount; i++ {
+		fmt.Fprintf(w, "%d", i+1)
+		for _, node := range instance.Cluster(i) {
+			fmt.Fprintf(w, " %d", node+1)
+		}
+		fmt.Fprintln(w, " -1")
+	}
+	fmt.Fprintln(w, "EOF")
+
+	return w.Flush()
+}
+
+func writeTSPLIBCoordinates(w io.Writer, coords []gtsp.NodeCoord) {
+	for i, c := range coords {
+		fmt.Fprintf(w, "%d %s %s\n", i+1, formatFloat(c.X), formatFloat(c.Y))
+	}
+}
+
+// reads whitespace separated values which may span multiple lines,
+// as TSPLIB sections don't require a particular line layout
+type tokenReader struct {
+	*lineReader
+	tokens []string
+}
+
+func (r *tokenReader) nextToken() (string, error) {
+	for len(r.tokens) == 0 {
+		line, err := r.readLine()
+		if err != nil {
+			return "", err
+		}
+		r.tokens = strings.Fields(line)
+	}
+	token := r.tokens[0]
+	r.tokens = r.tokens[1:]
+	return token, nil
+}
+
+func (r *tokenReader) nextInt() (int, error) {
+	token, err := r.nextToken()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, r.errorf("expected an integer, got %q", token)
+	}
+	return n, nil
+}
+
+func (r *tokenReader) nextFloat() (float64, error) {
+	token, err := r.nextToken()
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, r.errorf("expected a number, got %q", token)
+	}
+	return f, nil
+}
+
+// the section has to end on the line where its last value is
+func (r *tokenReader) endSection(name string) error {
+	if len(r.tokens) > 0 {
+		return r.errorf("unexpected value %q at the end of %s", r.tokens[0], name)
+	}
+	return nil
+}
+
+func (r *tokenReader) parsePositive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, r.errorf("`%s` expected to be a positive integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+func (r *tokenReader) readCoordSection(header tsplibHeader) ([]gtsp.NodeCoord, error) {
+	if header.dimension == 0 {
+		return nil, r.errorf("DIMENSION has to precede the coordinates")
+	}
+
+	coords := make([]gtsp.NodeCoord, header.dimension)
+	seen := make([]bool, header.dimension)
+	for i := 0; i < header.dimension; i++ {
+		node, err := r.nextInt()
+		if err != nil {
+			return nil, err
+		}
+		if node < 1 || node > header.dimension {
+			return nil, r.errorf("node %d is out of range [1, %d]", node, header.dimension)
+		}
+		if seen[node-1] {
+			return nil, r.errorf("node %d has more than one coordinate", node)
+		}
+		seen[node-1] = true
+
+		if coords[node-1].X, err = r.nextFloat(); err != nil {
+			return nil, err
+		}
+		if coords[node-1].Y, err = r.nextFloat(); err != nil {
+			return nil, err
+		}
+		if err := r.endSection("a coordinate"); err != nil {
+			return nil, err
+		}
+	}
+	return coords, nil
+}
+
+func (r *tokenReader) readEdgeWeightSection(header tsplibHeader) ([][]int, error) {
+	if header.dimension == 0 {
+		return nil, r.errorf("DIMENSION has to precede EDGE_WEIGHT_SECTION")
+	}
+	if header.weightType != "EXPLICIT" {
+		return nil, r.errorf("EDGE_WEIGHT_SECTION requires EXPLICIT edge weight type, got %q", header.weightType)
+	}
+
+	n := header.dimension
+	distances := make([][]int, n)
+	for i := range distances {
+		distances[i] = make([]int, n)
+	}
+
+	// every format but the full matrix lists only one triangle of a symmetric matrix.
+	// `include` tells whether the cell (i, j) is listed, in row-major order
+
+	var include func(i, j int) bool
+	switch header.weightFormat {
+	case "FULL_MATRIX":
+		include = func(i, j int) bool { return true }
+	case "UPPER_ROW":
+		include = func(i, j int) bool { return i < j }
+	case "LOWER_ROW":
+		include = func(i, j int) bool { return i > j }
+	case "UPPER_DIAG_ROW":
+		include = func(i, j int) bool { return i <= j }
+	case "LOWER_DIAG_ROW":
+		include = func(i, j int) bool { return i >= j }
+	default:
+		return nil, r.errorf("unsupported edge weight format %q", header.weightFormat)
+	}
+
+	full := header.weightFormat == "FULL_MATRIX"
+	if !full && header.typ == "AGTSP" {
+		return nil, r.errorf("asymmetric instances require FULL_MATRIX edge weight format")
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if !include(i, j) {
+				continue
+			}
+			d, err := r.nextInt()
+			if err != nil {
+				return nil, err
+			}
+			distances[i][j] = d
+			if !full {
+				distances[j][i] = d
+			}
+		}
+	}
+
+	return distances, r.endSection("EDGE_WEIGHT_SECTION")
+}
+
+func (r *tokenReader) readSetSection(header tsplibHeader) (map[int][]int, error) {
+	if header.dimension == 0 || header.sets == 0 {
+		return nil, r.errorf("DIMENSION and GTSP_SETS have to precede GTSP_SET_SECTION")
+	}
+
+	// each set is given by its number followed by its nodes, terminated by -1
+
+	clusters := make(map[int][]int)
+	seen := make([]bool, header.dimension)
+	total := 0
+
+	for i := 0; i < header.sets; i++ {
+		set, err := r.nextInt()
+		if err != nil {
+			return nil, err
+		}
+		if set < 1 || set > header.sets {
+			return nil, r.errorf("set %d is out of range [1, %d]", set, header.sets)
+		}
+		if _, ok := clusters[set-1]; ok {
+			return nil, r.errorf("set %d is listed more than once", set)
+		}
+
+		nodes := make([]int, 0)
+		for {
+			node, err := r.nextInt()
+			if err != nil {
+				return nil, err
+			}
+			if node == -1 {
+				break
+			}
+			if node < 1 || node > header.dimension {
+				return nil, r.errorf("node %d is out of range [1, %d]", node, header.dimension)
+			}
+			if seen[node-1] {
+				return nil, r.errorf("node %d belongs to more than one set", node)
+			}
+			seen[node-1] = true
+			nodes = append(nodes, node-1)
+		}
+
+		if len(nodes) == 0 {
+			return nil, r.errorf("set %d expected to have at least one node", set)
+		}
+		clusters[set-1] = nodes
+		total += len(nodes)
+	}
+
+	if total != header.dimension {
+		return nil, r.errorf("sets contain %d nodes in total, expected %d", total, header.dimension)
+	}
+	return clusters, r.endSection("GTSP_SET_SECTION")
+}
+
+// calculates the distance matrix for the given edge weight type as defined in
+// http://comopt.ifi.uni-heidelberg.de/software/TSPLIB95/tsp95.pdf
+func calculateTSPLIBDistances(weightType string, coords []gtsp.NodeCoord) ([][]int, error) {
+	var distance func(a, b gtsp.NodeCoord) int
+	switch weightType {
+	case "EUC_2D":
+		distance = euclideanDistance
+	case "MAN_2D":
+		distance = manhattanDistance
+	case "CEIL_2D":
+		distance = ceilDistance
+	case "ATT":
+		distance = pseudoEuclideanDistance
+	case "GEO":
+		distance = geographicalDistance
+	default:
+		return nil, fmt.Errorf("unsupported edge weight type %q", weightType)
+	}
+
+	n := len(coords)
+	distances := make([][]int, n)
+	for i := range distances {
+		distances[i] = make([]int, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d := distance(coords[i], coords[j])
+			distances[i][j] = d
+			distances[j][i] = d
+		}
+	}
+	return distances, nil
+}
+
+// rounds to the nearest integer, as `nint` in TSPLIB
+func nint(x float64) int {
+	return int(x + 0.5)
+}
+
+func euclideanDistance(a, b gtsp.NodeCoord) int {
+	return nint(math.Hypot(a.X-b.X, a.Y-b.Y))
+}
+
+func manhattanDistance(a, b gtsp.NodeCoord) int {
+	return nint(math.Abs(a.X-b.X) + math.Abs(a.Y-b.Y))
+}
+
+func ceilDistance(a, b gtsp.NodeCoord) int {
+	return int(math.Ceil(math.Hypot(a.X-b.X, a.Y-b.Y)))
+}
+
+func pseudoEuclideanDistance(a, b gtsp.NodeCoord) int {
+	xd := a.X - b.X
+	yd := a.Y - b.Y
+	r := math.Sqrt((xd*xd + yd*yd) / 10.0)
+	t := nint(r)
+	if float64(t) < r {
+		return t + 1
+	}
+	return t
+}
+
+func geographicalDistance(a, b gtsp.NodeCoord) int {
+
+	// coordinates are given as DDD.MM, i.e. degrees and minutes,
+	// which are converted to latitude and longitude in radians
+
+	const pi = 3.141592
+	const radius = 6378.388
+
+	toRadians := func(x float64) float64 {
+		deg := math.Trunc(x)
+		min := x - deg
+		return pi * (deg + 5.0*min/3.0) / 180.0
+	}
+
+	latA, lonA := toRadians(a.X), toRadians(a.Y)
+	latB, lonB := toRadians(b.X), toRadians(b.Y)
+
+	q1 := math.Cos(lonA - lonB)
+	q2 := math.Cos(latA - latB)
+	q3 := math.Cos(latA + latB)
+	return int(radius*math.Acos(0.5*((1.0+q1)*q2-(1.0-q1)*q3)) + 1.0)
+}
@@ -0,0 +1,203 @@
+package io
+
+import (
+	"bytes"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp/gtsptest"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestReadTSPLIB_Euclidean(t *testing.T) {
+	content := `NAME: 4test
+TYPE: GTSP
+COMMENT: small test instance
+DIMENSION: 4
+GTSP_SETS: 2
+EDGE_WEIGHT_TYPE: EUC_2D
+NODE_COORD_SECTION
+1 0 0
+2 3 4
+3 0 4.6
+4 6 8
+GTSP_SET_SECTION:
+1 1 3 -1
+2 2 4 -1
+EOF
+`
+	inst, err := ReadTSPLIB(strings.NewReader(content))
+	assert.True(t, err == nil)
+
+	assert.Equal(t, 4, inst.NodeCount)
+	assert.Equal(t, 2, inst.ClusterCount)
+	assert.True(t, inst.Symmetric)
+	assert.Equal(t, map[int][]int{0: {0, 2}, 1: {1, 3}}, inst.Clusters())
+
+	// 1-2 : sqrt(9 + 16) = 5
+	// 1-3 : 4.6, rounded to 5
+	// 1-4 : sqrt(36 + 64) = 10
+
+	assert.Equal(t, gtsp.Euclidean, inst.Metric)
+	assert.Equal(t, gtsp.NodeCoord{X: 0, Y: 4.6}, inst.Coordinates[2])
+	assert.Equal(t, []int{0, 5, 5, 10}, inst.DistanceMatrix()[0])
+	assert.Equal(t, 5, inst.GetDistance(1, 0))
+}
+
+func TestReadTSPLIB_ExplicitUpperRow(t *testing.T) {
+	content := `NAME: 3test
+TYPE: GTSP
+DIMENSION: 3
+GTSP_SETS: 2
+EDGE_WEIGHT_TYPE: EXPLICIT
+EDGE_WEIGHT_FORMAT: UPPER_ROW
+EDGE_WEIGHT_SECTION
+1 2
+3
+GTSP_SET_SECTION
+2 3 -1
+1 1 2
+-1
+EOF
+`
+	inst, err := ReadTSPLIB(strings.NewReader(content))
+	assert.True(t, err == nil)
+
+	expected := [][]int{
+		{0, 1, 2},
+		{1, 0, 3},
+		{2, 3, 0},
+	}
+	assert.Equal(t, expected, inst.Distances)
+	assert.Equal(t, map[int][]int{0: {0, 1}, 1: {2}}, inst.Clusters())
+}
+
+func TestReadTSPLIB_Asymmetric(t *testing.T) {
+	content := `TYPE: AGTSP
+DIMENSION: 2
+GTSP_SETS: 2
+EDGE_WEIGHT_TYPE: EXPLICIT
+EDGE_WEIGHT_FORMAT: FULL_MATRIX
+EDGE_WEIGHT_SECTION
+0 7
+3 0
+GTSP_SET_SECTION
+1 1 -1
+2 2 -1
+`
+	inst, err := ReadTSPLIB(strings.NewReader(content))
+	assert.True(t, err == nil)
+	assert.False(t, inst.Symmetric)
+	assert.Equal(t, [][]int{{0, 7}, {3, 0}}, inst.Distances)
+}
+
+func TestReadTSPLIB_Errors(t *testing.T) {
+	header := "TYPE: GTSP\nDIMENSION: 2\nGTSP_SETS: 2\nEDGE_WEIGHT_TYPE: EUC_2D\n"
+	coords := "NODE_COORD_SECTION\n1 0 0\n2 1 1\n"
+	sets := "GTSP_SET_SECTION\n1 1 -1\n2 2 -1\n"
+
+	cases := map[string]string{
+		"TYPE: TSP\n":          "line 1: unsupported type \"TSP\", expected GTSP or AGTSP",
+		"DIMENSION: -3\n":      "line 1: `DIMENSION` expected to be a positive integer, got \"-3\"",
+		"NODE_COORD_SECTION\n": "line 1: DIMENSION has to precede the coordinates",
+		header + "FOO: bar\n":  "line 5: unsupported keyword \"FOO\"",
+		header + coords:        "line 7: GTSP_SET_SECTION is missing",
+		header + "NODE_COORD_SECTION\n1 0 0\n3 1 1\n":                          "line 7: node 3 is out of range [1, 2]",
+		header + coords + "GTSP_SET_SECTION\n1 1 2 -1\n2 2 -1\n":               "line 10: node 2 belongs to more than one set",
+		header + coords + "GTSP_SET_SECTION\n1 1 -1\n1 2 -1\n":                 "line 10: set 1 is listed more than once",
+		header + coords + "GTSP_SET_SECTION\n1 1 -1\n2 2 -1 7\n":               "line 10: unexpected value \"7\" at the end of GTSP_SET_SECTION",
+		header + coords + "GTSP_SET_SECTION\n1 1 -1\n2 2\n":                    "line 11: unexpected end of file",
+		"DIMENSION: 2\nGTSP_SETS: 2\nEDGE_WEIGHT_TYPE: GEOM\n" + coords + sets: "unsupported edge weight type \"GEOM\"",
+	}
+
+	for content, expected := range cases {
+		_, err := ReadTSPLIB(strings.NewReader(content))
+		if assert.True(t, err != nil, content) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestWriteTSPLIB_RoundTrip(t *testing.T) {
+	inst, err := gtsp.NewInstance(25, 6, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	var buf bytes.Buffer
+	assert.True(t, WriteTSPLIB(&buf, inst) == nil)
+
+	imported, err := ReadTSPLIB(&buf)
+	assert.True(t, err == nil)
+
+	// neither the seed nor the triangle inequality flag are a part of the format,
+	// and the distances of an instance defined by coordinates are calculated on demand
+
+	assert.True(t, imported.Distances == nil)
+	assert.Equal(t, inst.GetDistance(3, 17), imported.GetDistance(3, 17))
+	imported.ComputeDistances()
+	imported.Seed = inst.Seed
+	imported.Triangle = inst.Triangle
+	assert.Equal(t, inst, imported)
+}
+
+func TestWriteTSPLIB_RoundTripAsymmetric(t *testing.T) {
+	inst, err := gtsp.NewInstance(25, 6, pkg.NewRand(1), gtsp.Asymmetric(20), gtsp.WithMetric(gtsp.Euclidean))
+	assert.True(t, err == nil)
+
+	var buf bytes.Buffer
+	assert.True(t, WriteTSPLIB(&buf, inst) == nil)
+	assert.Contains(t, buf.String(), "DISPLAY_DATA_SECTION")
+
+	imported, err := ReadTSPLIB(&buf)
+	assert.True(t, err == nil)
+
+	// the metric is lost along with the coordinates defining the distances
+
+	assert.Equal(t, inst.Coordinates, imported.Coordinates)
+	assert.Equal(t, inst.Distances, imported.Distances)
+	assert.Equal(t, inst.Clusters(), imported.Clusters())
+}
+
+func TestWriteTSPLIB_RoundTripProperty(t *testing.T) {
+
+	// the format has no notion of the triangle inequality flag, which is always false
+
+	property := func(seed int64) bool {
+		inst := gtsptest.RandomInstance(seed)
+		inst.Triangle = false
+
+		var buf bytes.Buffer
+		if err := WriteTSPLIB(&buf, inst); err != nil {
+			t.Log(err)
+			return false
+		}
+		imported, err := ReadTSPLIB(&buf)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		if diff := inst.Diff(imported); diff != "" {
+			t.Log(diff)
+			return false
+		}
+		return true
+	}
+	assert.True(t, quick.Check(property, nil) == nil)
+}
+
+func Test_TSPLIBDistances(t *testing.T) {
+	a := gtsp.NodeCoord{X: 0, Y: 0}
+	b := gtsp.NodeCoord{X: 3, Y: 4}
+
+	assert.Equal(t, 5, euclideanDistance(a, b))
+	assert.Equal(t, 5, ceilDistance(a, gtsp.NodeCoord{X: 3, Y: 3.9}))
+
+	// sqrt(25 / 10) = 1.58, rounded to 2
+
+	assert.Equal(t, 2, pseudoEuclideanDistance(a, b))
+
+	// 38°24' N 20°42' E to 39°57' N 26°15' E
+
+	assert.Equal(t, 509, geographicalDistance(gtsp.NodeCoord{X: 38.24, Y: 20.42}, gtsp.NodeCoord{X: 39.57, Y: 26.15}))
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// WritePNG draws the same picture as WriteSVG, rasterised with the standard library
+func WritePNG(writer io.Writer, instance *gtsp.Instance, solution *gtsp.Solution, options Options) error {
+	l, err := newLayout(instance, solution, options)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: backgroundColour}, image.Point{}, draw.Src)
+
+	for _, edge := range l.tour {
+		drawLine(img, edge[0], edge[1], tourColour)
+	}
+
+	for i, p := range l.points {
+		if l.visited[i] {
+			drawDisc(img, p, 2*l.radius+1, tourColour)
+			drawDisc(img, p, 2*l.radius, l.colours[l.cluster[i]])
+		} else {
+			drawDisc(img, p, l.radius, l.colours[l.cluster[i]])
+		}
+	}
+
+	return png.Encode(writer, img)
+}
+
+// draws a straight line by stepping along its longer axis one pixel at a time
+func drawLine(img *image.RGBA, from, to point, c color.RGBA) {
+	dx, dy := to.x-from.x, to.y-from.y
+	steps := int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy))))
+	if steps == 0 {
+		img.SetRGBA(int(from.x), int(from.y), c)
+		return
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		img.SetRGBA(int(math.Round(from.x+t*dx)), int(math.Round(from.y+t*dy)), c)
+	}
+}
+
+func drawDisc(img *image.RGBA, centre point, radius int, c color.RGBA) {
+	cx, cy := int(math.Round(centre.x)), int(math.Round(centre.y))
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.SetRGBA(cx+x, cy+y, c)
+			}
+		}
+	}
+}
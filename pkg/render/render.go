@@ -0,0 +1,142 @@
+package render
+
+import (
+	"errors"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"image/color"
+	"math"
+)
+
+// Options control the size and the look of the picture
+type Options struct {
+	// size of the longer side of the picture in pixels, the other one follows the aspect ratio
+	Size int
+	// radius of the nodes in pixels. nodes visited by the tour are drawn twice as large
+	NodeRadius int
+}
+
+func DefaultOptions() Options {
+	return Options{Size: 800, NodeRadius: 3}
+}
+
+var tourColour = color.RGBA{R: 40, G: 40, B: 40, A: 255}
+var backgroundColour = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+// point of the picture, in pixels from the top left corner
+type point struct {
+	x float64
+	y float64
+}
+
+// positions of the nodes and the clusters they belong to, shared by every output format
+type layout struct {
+	width   int
+	height  int
+	points  []point
+	cluster []int
+	colours []color.RGBA
+	tour    [][2]point
+	visited []bool
+	radius  int
+}
+
+func newLayout(instance *gtsp.Instance, solution *gtsp.Solution, options Options) (*layout, error) {
+	if !instance.HasCoordinates() {
+		return nil, errors.New("instance has no node coordinates to plot")
+	}
+	if options.Size <= 0 {
+		return nil, errors.New("picture size has to be positive")
+	}
+
+	// scale the bounding box of the coordinates to the picture, leaving a margin so
+	// that nodes on the border are drawn whole. the y axis points up, as on a plot
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range instance.Coordinates {
+		minX, maxX = math.Min(minX, c.X), math.Max(maxX, c.X)
+		minY, maxY = math.Min(minY, c.Y), math.Max(maxY, c.Y)
+	}
+
+	spanX := math.Max(maxX-minX, 1)
+	spanY := math.Max(maxY-minY, 1)
+	margin := float64(2*options.NodeRadius + 2)
+	scale := (float64(options.Size) - 2*margin) / math.Max(spanX, spanY)
+
+	l := &layout{
+		width:   int(math.Ceil(spanX*scale + 2*margin)),
+		height:  int(math.Ceil(spanY*scale + 2*margin)),
+		points:  make([]point, instance.NodeCount),
+		cluster: make([]int, instance.NodeCount),
+		colours: clusterColours(instance.ClusterCount),
+		visited: make([]bool, instance.NodeCount),
+		radius:  options.NodeRadius,
+	}
+	for i, c := range instance.Coordinates {
+		l.points[i] = point{
+			x: margin + (c.X-minX)*scale,
+			y: margin + (maxY-c.Y)*scale,
+		}
+	}
+	for cluster := 0; cluster < instance.ClusterCount; cluster++ {
+		for _, node := range instance.Cluster(cluster) {
+			l.cluster[node] = cluster
+		}
+	}
+
+	if solution == nil {
+		return l, nil
+	}
+
+	// follow the tour from the first cluster, one edge per cluster
+
+	cluster := 0
+	for i := 0; i < instance.ClusterCount; i++ {
+		next := solution.NextCluster[cluster]
+		from, to := solution.Vertices[cluster], solution.Vertices[next]
+		l.tour = append(l.tour, [2]point{l.points[from], l.points[to]})
+		l.visited[from] = true
+		cluster = next
+	}
+	return l, nil
+}
+
+// picks colours of evenly spread hues, so that neighbouring clusters are easy to tell apart
+func clusterColours(count int) []color.RGBA {
+	colours := make([]color.RGBA, count)
+	for i := range colours {
+
+		// the golden ratio keeps hues of consecutive clusters far apart,
+		// no matter how many clusters there are
+
+		hue := math.Mod(float64(i)*0.618033988749895, 1)
+		colours[i] = hsvToRGB(hue, 0.75, 0.85)
+	}
+	return colours
+}
+
+// converts a colour given by hue, saturation and value, each in [0, 1]
+func hsvToRGB(h, s, v float64) color.RGBA {
+	sector := math.Floor(h * 6)
+	f := h*6 - sector
+	p := v * (1 - s)
+	q := v * (1 - f*s)
+	t := v * (1 - (1-f)*s)
+
+	var r, g, b float64
+	switch int(sector) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return color.RGBA{R: uint8(r*255 + 0.5), G: uint8(g*255 + 0.5), B: uint8(b*255 + 0.5), A: 255}
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"bytes"
+	"github.com/olegnalivajev/cmcs/pkg"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestWriteSVG(t *testing.T) {
+	inst, err := gtsp.NewInstance(20, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	var buf bytes.Buffer
+	assert.True(t, WriteSVG(&buf, inst, solution, DefaultOptions()) == nil)
+
+	// a circle for every node, and a line for every edge of the tour
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Equal(t, 20, strings.Count(svg, "<circle "))
+	assert.Equal(t, 5, strings.Count(svg, "<line "))
+	assert.Equal(t, 5, strings.Count(svg, "stroke=\"#282828\">"))
+}
+
+func TestWriteSVG_WithoutSolution(t *testing.T) {
+	inst, err := gtsp.NewInstance(20, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+
+	var buf bytes.Buffer
+	assert.True(t, WriteSVG(&buf, inst, nil, DefaultOptions()) == nil)
+
+	assert.Equal(t, 20, strings.Count(buf.String(), "<circle "))
+	assert.Equal(t, 0, strings.Count(buf.String(), "<line "))
+}
+
+func TestWritePNG(t *testing.T) {
+	inst, err := gtsp.NewInstance(20, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+	solution := gtsp.GenerateSolution(inst, pkg.NewRand(1))
+
+	var buf bytes.Buffer
+	assert.True(t, WritePNG(&buf, inst, solution, Options{Size: 300, NodeRadius: 2}) == nil)
+
+	img, err := png.Decode(&buf)
+	assert.True(t, err == nil)
+
+	// the longer side matches the requested size
+
+	bounds := img.Bounds()
+	assert.True(t, bounds.Dx() <= 300 && bounds.Dy() <= 300)
+	assert.True(t, bounds.Dx() >= 299 || bounds.Dy() >= 299)
+}
+
+func TestWriteSVG_NoCoordinates(t *testing.T) {
+	inst, err := gtsp.NewInstance(20, 5, pkg.NewRand(1))
+	assert.True(t, err == nil)
+	inst.Coordinates = nil
+
+	var buf bytes.Buffer
+	err = WriteSVG(&buf, inst, nil, DefaultOptions())
+	if assert.True(t, err != nil) {
+		assert.Equal(t, "instance has no node coordinates to plot", err.Error())
+	}
+}
+
+func Test_ClusterColoursAreDistinct(t *testing.T) {
+	colours := clusterColours(50)
+	seen := make(map[string]bool)
+	for _, c := range colours {
+		assert.False(t, seen[hex(c)], hex(c))
+		seen[hex(c)] = true
+	}
+}
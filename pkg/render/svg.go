@@ -0,0 +1,58 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/olegnalivajev/cmcs/pkg/gtsp"
+	"image/color"
+	"io"
+)
+
+// WriteSVG draws the nodes of the instance coloured by their clusters and, unless
+// the solution is nil, the tour on top of them
+func WriteSVG(writer io.Writer, instance *gtsp.Instance, solution *gtsp.Solution, options Options) error {
+	l, err := newLayout(instance, solution, options)
+	if err != nil {
+		return err
+	}
+
+	// bufio.Writer remembers the first error it ran into,
+	// so it's enough to check the result of Flush
+
+	w := bufio.NewWriter(writer)
+
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		l.width, l.height, l.width, l.height)
+	fmt.Fprintf(w, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", hex(backgroundColour))
+
+	// nodes are drawn after the tour, so that the tour doesn't hide them
+
+	if len(l.tour) > 0 {
+		fmt.Fprintf(w, "<g stroke=\"%s\" stroke-width=\"1.5\">\n", hex(tourColour))
+		for _, edge := range l.tour {
+			fmt.Fprintf(w, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>\n",
+				edge[0].x, edge[0].y, edge[1].x, edge[1].y)
+		}
+		fmt.Fprintln(w, "</g>")
+	}
+
+	fmt.Fprintln(w, "<g>")
+	for i, p := range l.points {
+		radius := l.radius
+		stroke := ""
+		if l.visited[i] {
+			radius *= 2
+			stroke = fmt.Sprintf(" stroke=\"%s\"", hex(tourColour))
+		}
+		fmt.Fprintf(w, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"%d\" fill=\"%s\"%s><title>node %d, cluster %d</title></circle>\n",
+			p.x, p.y, radius, hex(l.colours[l.cluster[i]]), stroke, i, l.cluster[i])
+	}
+	fmt.Fprintln(w, "</g>")
+
+	fmt.Fprintln(w, "</svg>")
+	return w.Flush()
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}